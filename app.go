@@ -2,105 +2,125 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"log"
-	"net/http"
-	"os"
 	"path/filepath"
+	"strings"
 	"sync"
-	"time"
 
-	cd "mg-Downloader/pkg/comicDays"
-	of "mg-Downloader/pkg/ourfeel"
-	ps "mg-Downloader/pkg/pocketShonenmagazine"
+	"mg-Downloader/pkg/config"
+	"mg-Downloader/pkg/cookies"
+	"mg-Downloader/pkg/downloader"
+	"mg-Downloader/pkg/exporter"
+	"mg-Downloader/pkg/pathtmpl"
+	"mg-Downloader/pkg/provider"
+	"mg-Downloader/pkg/selection"
+
+	_ "mg-Downloader/pkg/comicDays"
+	_ "mg-Downloader/pkg/ourfeel"
+	_ "mg-Downloader/pkg/pocketShonenmagazine"
 )
 
+// queueFileName 下载队列文件名，与设置文件放在同一目录
+const queueFileName = "queue.json"
+
 type ComicInfo struct {
+	EpisodeID string `json:"episode_id"`
 	Mode      string `json:"mode"`
 	Title     string `json:"title"`
+	// Thumbnail 站点提供的缩略图地址，没有时为空，可以用 GetThumbnail 获取
 	Thumbnail string `json:"thumbnail"`
 	PageURL   string `json:"page_url"`
 }
 
+// DownloadOptions 前端下载时的可选参数
+type DownloadOptions struct {
+	// Format 输出格式，为空时保存为散图
+	Format string `json:"format"`
+	// LeftToRight 打包时按从左向右翻页，默认按日本漫画从右向左
+	LeftToRight bool `json:"left_to_right"`
+	// Pages 只下载部分页，如 "1-5,20-"，为空时下载整话
+	Pages string `json:"pages"`
+	// Template 输出路径模板，如 "{series}/{episode_number:03} {episode_title}/{page:03}.{ext}"
+	Template string `json:"template"`
+	// OutDir 保存路径，为空时使用设置中的书库目录，两者都没有时弹出选择框
+	OutDir string `json:"out_dir"`
+}
+
 type DownloadProgress struct {
+	JobID   string `json:"job_id"`
 	Current int    `json:"current"`
 	Total   int    `json:"total"`
 	Title   string `json:"title"`
 	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
 }
 
 type App struct {
-	ctx                context.Context
-	currentMode        string
-	isDownloading      bool
-	forceStop          bool // 新增：强制停止标志
-	downloadCancelChan chan struct{}
-	progressStopChan   chan struct{}
-	downloadMutex      sync.RWMutex
-	eventListeners     map[string]func()
-	downloadSessionId  int64     // 新增：下载会话ID
-	lastCancelTime     time.Time // 新增：最后取消时间
+	ctx      context.Context
+	episodes *episodeRegistry
+	queue    *downloader.Queue
+
+	mu           sync.Mutex
+	settings     *config.Settings
+	settingsPath string
 }
 
 func NewApp() *App {
 	return &App{
-		downloadCancelChan: make(chan struct{}, 1),
-		progressStopChan:   make(chan struct{}, 1),
-		eventListeners:     make(map[string]func()),
-		forceStop:          false,
-		downloadSessionId:  0,
+		episodes:     newEpisodeRegistry(),
+		settingsPath: config.DefaultPath(),
 	}
 }
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+
+	settings, err := config.Load(a.settingsPath)
+	if err != nil {
+		// 设置文件有误时使用默认设置，避免应用无法启动
+		log.Printf("[Backend] ⚠️ 读取设置失败: %v", err)
+	}
+	settings.Apply()
+	a.settings = settings
+
+	queueFile := filepath.Join(filepath.Dir(a.settingsPath), queueFileName)
+	queue, err := downloader.NewQueue(queueFile, settings.Downloads.MaxConcurrent, a.emitProgress)
+	if err != nil {
+		// 队列文件损坏时从空队列开始，避免应用无法启动
+		log.Printf("[Backend] ⚠️ 恢复下载队列失败: %v", err)
+		queue, _ = downloader.NewQueue("", settings.Downloads.MaxConcurrent, a.emitProgress)
+	}
+	queue.SetDefaultPageWorkers(settings.Downloads.PageWorkers)
+	a.queue = queue
+	a.queue.Start(ctx)
+
 	log.Println("[Backend] 应用启动完成")
 }
 
 func (a *App) SearchComics(mode string, query string) ([]ComicInfo, error) {
-	// 检查是否刚刚被取消
-	a.downloadMutex.RLock()
-	recentlyCancelled := !a.lastCancelTime.IsZero() && time.Since(a.lastCancelTime) < 2*time.Second
-	a.downloadMutex.RUnlock()
+	log.Printf("[Backend] 搜索: %s - %s", mode, query)
 
-	if recentlyCancelled {
-		log.Println("[Backend] ⚠️ 最近有取消操作，等待清理")
-		time.Sleep(200 * time.Millisecond)
+	p, err := provider.Get(mode)
+	if err != nil {
+		return nil, err
 	}
 
-	time.Sleep(1 * time.Second)
+	ep, err := p.FetchEpisode(a.ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(ep.Pages) == 0 {
+		return nil, fmt.Errorf("章节数据中没有找到图片")
+	}
 
-	var comics []ComicInfo
-	log.Printf("[Backend] 搜索: %s - %s", mode, query)
+	episodeID := a.episodes.Add(p, ep)
 
-	switch mode {
-	case "comicDays":
-		mgTitle, picSrc, err := cd.GetFirstPageFromComicDays(query)
-		if err != nil {
-			return nil, err
-		}
-		comics = []ComicInfo{
-			{Mode: mode, Title: mgTitle, Thumbnail: picSrc, PageURL: query},
-		}
-	case "ourfeel":
-		mgTitle, picSrc, err := of.GetFirstPageFromOurfeel(query)
-		if err != nil {
-			return nil, err
-		}
-		comics = []ComicInfo{
-			{Mode: mode, Title: mgTitle, Thumbnail: picSrc, PageURL: query},
-		}
-	case "PocketShonenmagazine":
-		mgTitle, picSrc, err := ps.GetFirstPageFromPocketShonenmagazine(query)
-		if err != nil {
-			return nil, err
-		}
-		comics = []ComicInfo{
-			{Mode: mode, Title: mgTitle, Thumbnail: picSrc, PageURL: query},
-		}
-	default:
-		return nil, fmt.Errorf("未知模式: %s", mode)
+	// 缩略图使用站点提供的地址，站点没有时前端可以用 GetThumbnail 再获取
+	comics := []ComicInfo{
+		{EpisodeID: episodeID, Mode: mode, Title: ep.Title, Thumbnail: ep.Thumbnail, PageURL: query},
 	}
 
 	var filtered []ComicInfo
@@ -113,491 +133,324 @@ func (a *App) SearchComics(mode string, query string) ([]ComicInfo, error) {
 	return filtered, nil
 }
 
-func (a *App) DownloadComicPage(comic ComicInfo) error {
-	log.Printf("[Backend] 🚀 开始下载: %s", comic.Title)
-
-	// 检查强制停止标志
-	if a.isForceStop() {
-		log.Println("[Backend] ❌ 强制停止中，拒绝新下载")
-		return fmt.Errorf("下载已被强制停止")
+// GetThumbnail 下载搜索到的章节的第一页作为缩略图，返回 data URL。
+// 会下载并解混淆整张图片，只在站点没有提供缩略图地址时使用
+func (a *App) GetThumbnail(episodeID string) (string, error) {
+	entry, err := a.episodes.Get(episodeID)
+	if err != nil {
+		return "", err
 	}
+	cover, err := entry.Provider.FetchPage(a.ctx, entry.Episode, 0)
+	if err != nil {
+		return "", err
+	}
+	return dataURL(cover), nil
+}
 
-	// 生成新的下载会话ID
-	a.downloadMutex.Lock()
-	sessionId := a.downloadSessionId + 1
-	a.downloadSessionId = sessionId
-	a.isDownloading = true
-	a.forceStop = false
-	a.currentMode = comic.Mode
-	a.downloadMutex.Unlock()
-
-	log.Printf("[Backend] 📋 下载会话 ID: %d", sessionId)
-
-	// 重置所有通道
-	a.clearAllChannels()
+// DownloadComicPage 将搜索到的章节加入下载队列，返回任务ID
+func (a *App) DownloadComicPage(comic ComicInfo, opts DownloadOptions) (string, error) {
+	log.Printf("[Backend] 🚀 加入下载: %s", comic.Title)
+	opts = a.withDefaults(opts)
 
-	// 选择保存路径
-	outDir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "保存路径",
-	})
+	entry, err := a.episodes.Get(comic.EpisodeID)
 	if err != nil {
-		a.cleanupDownloadState()
-		return fmt.Errorf("选择路径失败: %w", err)
+		return "", err
 	}
 
-	if outDir == "" {
-		a.cleanupDownloadState()
-		return fmt.Errorf("未选择路径")
+	outDir, library, err := a.outputDir(opts)
+	if err != nil {
+		return "", err
 	}
-
-	// 获取总页数
-	var totalPages int
-	var comicTitle string
-
-	switch comic.Mode {
-	case "comicDays":
-		if cd.COMIC_DAYS_INFO == nil {
-			a.cleanupDownloadState()
-			return fmt.Errorf("请先搜索")
-		}
-		totalPages = len(cd.COMIC_DAYS_INFO.Pages)
-		comicTitle = comic.Title
-	case "ourfeel":
-		if of.OURFEEL_INFO == nil {
-			a.cleanupDownloadState()
-			return fmt.Errorf("请先搜索")
-		}
-		totalPages = len(of.OURFEEL_INFO.Pages)
-		comicTitle = comic.Title
-	case "PocketShonenmagazine":
-		if ps.EpisodeData == nil {
-			a.cleanupDownloadState()
-			return fmt.Errorf("请先搜索")
+	if library {
+		// 书库目录由所有章节共用，散图放进每一话的文件夹
+		tmpl, err := pathtmpl.Parse(opts.Template)
+		if err != nil {
+			return "", err
 		}
-		totalPages = len(ps.EpisodeData.PageList)
-		fmt.Println(totalPages)
-		comicTitle = comic.Title
-	default:
-		a.cleanupDownloadState()
-
-		return fmt.Errorf("不支持的模式: %s", comic.Mode)
-	}
-
-	// 发送开始进度
-	if err := a.sendProgressSafely(DownloadProgress{
-		Current: 0,
-		Total:   totalPages,
-		Title:   comicTitle,
-		Status:  "started",
-	}, sessionId); err != nil {
-		a.cleanupDownloadState()
-		return err
+		outDir = downloader.EpisodeOutDir(outDir, entry.Episode, opts.Format, tmpl)
 	}
 
-	// 执行下载
-	var downloadErr error
-	if comic.Mode == "comicDays" {
-		downloadErr = a.downloadComicDays(outDir, totalPages, comicTitle, sessionId)
-	} else if comic.Mode == "ourfeel" {
-		downloadErr = a.downloadOurfeel(outDir, totalPages, comicTitle, sessionId)
-	} else if comic.Mode == "PocketShonenmagazine" {
-		downloadErr = a.downloadPocketShonenmagazine(outDir, totalPages, comicTitle, sessionId)
+	job, err := a.queue.Enqueue(entry.Provider, entry.Episode, downloader.Request{
+		Title:       comic.Title,
+		OutDir:      outDir,
+		Format:      opts.Format,
+		LeftToRight: opts.LeftToRight,
+		Pages:       opts.Pages,
+		Template:    opts.Template,
+	})
+	if err != nil {
+		return "", err
 	}
-
-	// 清理状态
-	a.cleanupDownloadState()
-
-	return downloadErr
+	return job.ID, nil
 }
 
-func (a *App) downloadComicDays(outDir string, totalPages int, title string, sessionId int64) error {
-	log.Printf("[Backend] 下载ComicDays: %s (%d页) [会话:%d]", title, totalPages, sessionId)
+// GetSeries 获取作品信息和全部章节，url 可以是作品页或站点支持的章节链接
+func (a *App) GetSeries(mode string, url string) (*provider.Series, error) {
+	log.Printf("[Backend] 获取作品: %s - %s", mode, url)
 
-	for i, page := range cd.COMIC_DAYS_INFO.Pages {
-		// 检查是否应该停止（带会话ID检查）
-		if a.shouldStopDownload(sessionId) {
-			log.Printf("[Backend] ❌ 会话 %d 检测到停止，退出下载", sessionId)
-			return nil
-		}
-
-		// 检查取消通道
-		select {
-		case <-a.downloadCancelChan:
-			log.Printf("[Backend] ❌ 会话 %d 收到取消信号", sessionId)
-			a.setForceStop()
-			return nil
-		case <-a.progressStopChan:
-			log.Printf("[Backend] ❌ 会话 %d 收到停止进度信号", sessionId)
-			a.setForceStop()
-		default:
-		}
-
-		pageNum := i + 1
-
-		// 发送进度
-		if err := a.sendProgressSafely(DownloadProgress{
-			Current: pageNum,
-			Total:   totalPages,
-			Title:   title,
-			Status:  "downloading",
-		}, sessionId); err != nil {
-			log.Printf("[Backend] ⚠️ 发送进度失败: %v", err)
-		}
-
-		// 处理页面
-		page.Process(cd.COMIC_DAYS_INFO.NetworkClient, cd.COMIC_DAYS_INFO.Cookies, outDir, pageNum)
-
-		// 每个页面后再次检查
-		if a.shouldStopDownload(sessionId) {
-			log.Printf("[Backend] ❌ 页面处理后检测到停止")
-			return nil
-		}
+	p, err := provider.Get(mode)
+	if err != nil {
+		return nil, err
 	}
-
-	// 发送完成
-	if !a.isForceStop() {
-		a.sendProgressSafely(DownloadProgress{
-			Current: totalPages,
-			Total:   totalPages,
-			Title:   title,
-			Status:  "completed",
-		}, sessionId)
+	sp, ok := p.(provider.SeriesProvider)
+	if !ok || !sp.MatchSeries(url) {
+		return nil, fmt.Errorf("%s 不支持该作品链接", mode)
 	}
-
-	return nil
+	return sp.FetchSeries(a.ctx, url)
 }
 
-func (a *App) downloadOurfeel(outDir string, totalPages int, title string, sessionId int64) error {
-	log.Printf("[Backend] 下载Ourfeel: %s (%d页) [会话:%d]", title, totalPages, sessionId)
-
-	for i, page := range of.OURFEEL_INFO.Pages {
-		// 检查是否应该停止
-		if a.shouldStopDownload(sessionId) {
-			log.Printf("[Backend] ❌ 会话 %d 检测到停止，退出下载", sessionId)
-			return nil
-		}
-
-		// 检查取消通道
-		select {
-		case <-a.downloadCancelChan:
-			log.Printf("[Backend] ❌ 会话 %d 收到取消信号", sessionId)
-			a.setForceStop()
-			return nil
-		case <-a.progressStopChan:
-			log.Printf("[Backend] ❌ 会话 %d 收到停止进度信号", sessionId)
-			a.setForceStop()
-		default:
-		}
-
-		pageNum := i + 1
+// SelectEpisodes 按选择表达式（如 "1-10,15,latest:3,free"）筛选作品中的章节
+func (a *App) SelectEpisodes(series provider.Series, expr string) ([]provider.SeriesEpisode, error) {
+	sel, err := selection.ParseEpisodes(expr)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Apply(series.Episodes), nil
+}
 
-		// 发送进度
-		if err := a.sendProgressSafely(DownloadProgress{
-			Current: pageNum,
-			Total:   totalPages,
-			Title:   title,
-			Status:  "downloading",
-		}, sessionId); err != nil {
-			log.Printf("[Backend] ⚠️ 发送进度失败: %v", err)
-		}
+// DownloadSeries 将作品中选中的章节作为一批任务加入队列，最多选择一次保存路径，返回任务ID
+func (a *App) DownloadSeries(mode string, series provider.Series, episodes []provider.SeriesEpisode, opts DownloadOptions) ([]string, error) {
+	if len(episodes) == 0 {
+		return nil, fmt.Errorf("没有选择章节")
+	}
+	log.Printf("[Backend] 🚀 批量加入下载: %s (%d话)", series.Title, len(episodes))
+	opts = a.withDefaults(opts)
 
-		// 处理页面
-		page.Process(of.OURFEEL_INFO.NetworkClient, outDir, pageNum)
+	outDir, _, err := a.outputDir(opts)
+	if err != nil {
+		return nil, err
+	}
 
-		// 每个页面后再次检查
-		if a.shouldStopDownload(sessionId) {
-			log.Printf("[Backend] ❌ 页面处理后检测到停止")
-			return nil
-		}
+	tmpl, err := pathtmpl.Parse(opts.Template)
+	if err != nil {
+		return nil, err
 	}
 
-	// 发送完成
-	if !a.isForceStop() {
-		a.sendProgressSafely(DownloadProgress{
-			Current: totalPages,
-			Total:   totalPages,
-			Title:   title,
-			Status:  "completed",
-		}, sessionId)
+	var ids []string
+	for _, e := range episodes {
+		job, err := a.queue.EnqueueURL(mode, e.URL, downloader.Request{
+			Title:       strings.TrimSpace(series.Title + " " + e.Title),
+			OutDir:      downloader.BatchOutDir(outDir, e, opts.Format, tmpl),
+			Format:      opts.Format,
+			LeftToRight: opts.LeftToRight,
+			Pages:       opts.Pages,
+			Template:    opts.Template,
+		})
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, job.ID)
 	}
+	return ids, nil
+}
 
-	return nil
+// GetSettings 返回当前设置
+func (a *App) GetSettings() config.Settings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return *a.settings
 }
 
-func (a *App) downloadPocketShonenmagazine(outDir string, totalPages int, title string, sessionId int64) error {
-	log.Printf("[Backend] 下载PocketShonenmagazine: %s (%d页) [会话:%d]", title, totalPages, sessionId)
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// SaveSettings 保存设置并立即生效
+func (a *App) SaveSettings(settings config.Settings) error {
+	settings.Normalize()
+	if err := settings.Validate(); err != nil {
+		return err
 	}
-	config := ps.DownloadConfig{
-		OutputDir: outDir,
-		TileCount: 4,
-		Timeout:   30 * time.Second,
-		Client:    client,
+	if err := settings.Save(a.settingsPath); err != nil {
+		return err
 	}
-	for i, imgURL := range ps.EpisodeData.PageList {
-		// 检查是否应该停止
-		if a.shouldStopDownload(sessionId) {
-			log.Printf("[Backend] ❌ 会话 %d 检测到停止，退出下载", sessionId)
-			return nil
-		}
 
-		// 检查取消通道
-		select {
-		case <-a.downloadCancelChan:
-			log.Printf("[Backend] ❌ 会话 %d 收到取消信号", sessionId)
-			a.setForceStop()
-			return nil
-		case <-a.progressStopChan:
-			log.Printf("[Backend] ❌ 会话 %d 收到停止进度信号", sessionId)
-			a.setForceStop()
-		default:
-		}
-
-		pageNum := i + 1
+	settings.Apply()
+	a.queue.SetConcurrency(settings.Downloads.MaxConcurrent)
+	a.queue.SetDefaultPageWorkers(settings.Downloads.PageWorkers)
 
-		// 发送进度
-		if err := a.sendProgressSafely(DownloadProgress{
-			Current: pageNum,
-			Total:   totalPages,
-			Title:   title,
-			Status:  "downloading",
-		}, sessionId); err != nil {
-			log.Printf("[Backend] ⚠️ 发送进度失败: %v", err)
-		}
-
-		// 处理页面
-		//page.Process(of.OURFEEL_INFO.NetworkClient, outDir, pageNum)
-		if len(ps.EpisodeData.PageList) == 0 {
-			return fmt.Errorf("章节数据中没有找到图片")
-		}
-
-		fmt.Printf("找到 %d 张图片，scramble_seed: %d\n", len(ps.EpisodeData.PageList), ps.EpisodeData.ScrambleSeed)
-
-		// 创建输出目录
-		if err := os.MkdirAll(config.OutputDir, 0755); err != nil {
-			return fmt.Errorf("创建输出目录失败: %w", err)
-		}
-
-		// 顺序下载图片
-		var successCount int
-		var failedCount int
-
-		fmt.Printf("正在下载第 %d/%d 页...\n", pageNum, len(ps.EpisodeData.PageList))
+	a.mu.Lock()
+	a.settings = &settings
+	a.mu.Unlock()
+	log.Printf("[Backend] 设置已保存: %s", a.settingsPath)
+	return nil
+}
 
-		// 下载图片
-		imgData, err := ps.DownloadImage(imgURL, config.Client, config.Timeout)
+// ImportCookies 导入 cookie 到站点的 cookie 文件，返回每个站点导入的条数。
+// format 为 json、netscape、header、har 或留空自动判断；mode 为空时按域名导入到所有站点。
+func (a *App) ImportCookies(mode string, format string, data string) (map[string]int, error) {
+	var sites []provider.Provider
+	if mode != "" {
+		p, err := provider.Get(mode)
 		if err != nil {
-			fmt.Printf("❌ 第 %d 页下载失败: %v\n", pageNum, err)
-			failedCount++
-			continue
+			return nil, err
 		}
+		sites = append(sites, p)
+	}
+	result, err := cookies.ImportSites([]byte(data), format, sites)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Backend] 已导入 cookie: %v", result)
+	return result, nil
+}
 
-		// 处理图片（解扰）
-		processedData, err := ps.ProcessImage(imgData, ps.EpisodeData.ScrambleSeed, config.TileCount)
+// ImportBrowserCookies 从 Firefox 或 Chromium 系浏览器的配置目录（或 cookie 数据库文件）导入 cookie，
+// 返回每个站点导入的条数；mode 为空时按域名导入到所有站点。
+func (a *App) ImportBrowserCookies(mode string, profilePath string) (map[string]int, error) {
+	var sites []provider.Provider
+	if mode != "" {
+		p, err := provider.Get(mode)
 		if err != nil {
-			fmt.Printf("❌ 第 %d 页处理失败: %v\n", pageNum, err)
-			failedCount++
-			continue
-		}
-
-		// 保存图片文件
-		filename := fmt.Sprintf("%03d.jpg", pageNum)
-		filepath := filepath.Join(config.OutputDir, filename)
-		if err := ps.SaveImage(processedData, filepath); err != nil {
-			fmt.Printf("❌ 第 %d 页保存失败: %v\n", pageNum, err)
-			failedCount++
-			continue
-		}
-
-		successCount++
-		fmt.Printf("✓ 第 %d 页下载完成: %s\n", pageNum, filename)
-
-		// 添加短暂延迟，避免请求过快
-		if pageNum < len(ps.EpisodeData.PageList) {
-			time.Sleep(500 * time.Millisecond)
-		}
-
-		// 每个页面后再次检查
-		if a.shouldStopDownload(sessionId) {
-			log.Printf("[Backend] ❌ 页面处理后检测到停止")
-			return nil
+			return nil, err
 		}
+		sites = append(sites, p)
 	}
-
-	// 发送完成
-	if !a.isForceStop() {
-		a.sendProgressSafely(DownloadProgress{
-			Current: totalPages,
-			Total:   totalPages,
-			Title:   title,
-			Status:  "completed",
-		}, sessionId)
+	result, err := cookies.ImportBrowser(profilePath, sites)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	log.Printf("[Backend] 已从浏览器导入 cookie: %v", result)
+	return result, nil
 }
 
-// 新增：带会话ID的停止检查
-func (a *App) shouldStopDownload(sessionId int64) bool {
-	a.downloadMutex.RLock()
-	defer a.downloadMutex.RUnlock()
-
-	// 检查强制停止
-	if a.forceStop {
-		return true
+// CheckLogin 检查站点保存的 cookie 是否仍处于登录状态，返回登录的用户和 cookie 的过期时间。
+// 未登录或 cookie 过期时返回 LoggedIn 为 false 的状态，Reason 说明原因；无法访问站点时返回错误。
+func (a *App) CheckLogin(mode string) (*provider.LoginStatus, error) {
+	p, err := provider.Get(mode)
+	if err != nil {
+		return nil, err
 	}
-
-	// 检查会话是否过期（防止旧会话继续）
-	if a.downloadSessionId != sessionId {
-		log.Printf("[Backend] ⚠️ 会话 %d 已过期，当前会话: %d", sessionId, a.downloadSessionId)
-		return true
+	status, err := provider.CheckLogin(a.ctx, p)
+	if err != nil && !provider.IsLoginError(err) {
+		return nil, err
 	}
-
-	return false
+	log.Printf("[Backend] %s 登录状态: %v %s", mode, status.LoggedIn, status.Reason)
+	return status, nil
 }
 
-// 新增：检查强制停止
-func (a *App) isForceStop() bool {
-	a.downloadMutex.RLock()
-	defer a.downloadMutex.RUnlock()
-	return a.forceStop
+// Login 用用户名和密码登录站点，登录 cookie 保存到站点的 cookie 文件
+func (a *App) Login(mode string, user string, password string) (*provider.LoginStatus, error) {
+	p, err := provider.Get(mode)
+	if err != nil {
+		return nil, err
+	}
+	status, err := provider.Login(a.ctx, p, user, password)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Backend] %s 登录成功", mode)
+	return status, nil
 }
 
-// 新增：设置强制停止
-func (a *App) setForceStop() {
-	a.downloadMutex.Lock()
-	a.forceStop = true
-	a.downloadMutex.Unlock()
+// withDefaults 用设置中的默认格式和路径模板补全下载参数
+func (a *App) withDefaults(opts DownloadOptions) DownloadOptions {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if opts.Format == "" {
+		opts.Format = a.settings.Downloads.Format
+	}
+	if opts.Template == "" {
+		opts.Template = a.settings.Downloads.Template
+	}
+	return opts
 }
 
-func (a *App) CancelDownload() error {
-	log.Println("[Backend] 🚨 收到强制取消请求")
-
-	a.downloadMutex.Lock()
-
-	// 记录取消时间
-	a.lastCancelTime = time.Now()
-
-	if !a.isDownloading {
-		a.downloadMutex.Unlock()
-		log.Println("[Backend] 没有活跃下载")
-		return nil
+// outputDir 确定保存路径：优先使用本次指定的路径，其次是设置中的书库目录，都没有时才弹出选择框。
+// library 表示使用的是书库目录。
+func (a *App) outputDir(opts DownloadOptions) (dir string, library bool, err error) {
+	if opts.OutDir != "" {
+		return opts.OutDir, false, nil
 	}
 
-	// 设置强制停止标志
-	a.forceStop = true
-	log.Println("[Backend] ✅ 已设置强制停止标志")
-	a.downloadMutex.Unlock()
-
-	// 发送强停止信号
-	a.sendForceStopSignals()
-
-	// 强制清理
-	a.forceCleanupAll()
+	a.mu.Lock()
+	libraryDir := a.settings.Downloads.LibraryDir
+	a.mu.Unlock()
+	if libraryDir != "" {
+		return libraryDir, true, nil
+	}
 
-	log.Println("[Backend] ✅ 强制取消完成")
-	return nil
+	// 选择保存路径
+	outDir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "保存路径",
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("选择路径失败: %w", err)
+	}
+	if outDir == "" {
+		return "", false, fmt.Errorf("未选择路径")
+	}
+	return outDir, false, nil
 }
 
-func (a *App) sendForceStopSignals() {
-	// 清空通道
-	a.clearAllChannels()
-
-	// 发送多次停止信号确保接收
-	for i := 0; i < 3; i++ {
-		select {
-		case a.downloadCancelChan <- struct{}{}:
-			log.Println("[Backend] 发送取消信号")
-		default:
-		}
-
-		select {
-		case a.progressStopChan <- struct{}{}:
-			log.Println("[Backend] 发送停止进度信号")
-		default:
-		}
-
-		// 短暂延迟
-		time.Sleep(10 * time.Millisecond)
-	}
+// CancelDownload 取消指定任务
+func (a *App) CancelDownload(jobID string) error {
+	log.Printf("[Backend] 🚨 收到取消请求: %s", jobID)
+	return a.queue.Cancel(jobID)
 }
 
-func (a *App) clearAllChannels() {
-	// 清空下载取消通道
-	for {
-		select {
-		case <-a.downloadCancelChan:
-			continue
-		default:
-		}
-		break
-	}
+// PauseDownload 暂停指定任务，已保存的页面会保留
+func (a *App) PauseDownload(jobID string) error {
+	log.Printf("[Backend] ⏸️ 收到暂停请求: %s", jobID)
+	return a.queue.Pause(jobID)
+}
 
-	// 清空进度停止通道
-	for {
-		select {
-		case <-a.progressStopChan:
-			continue
-		default:
-		}
-		break
-	}
+// ResumeDownload 继续暂停的任务，从下一张未保存的页面开始
+func (a *App) ResumeDownload(jobID string) error {
+	log.Printf("[Backend] ▶️ 收到继续请求: %s", jobID)
+	return a.queue.Resume(jobID)
 }
 
-func (a *App) forceCleanupAll() {
-	a.downloadMutex.Lock()
-	defer a.downloadMutex.Unlock()
+// RemoveDownload 从列表中移除已结束的任务
+func (a *App) RemoveDownload(jobID string) error {
+	return a.queue.Remove(jobID)
+}
 
-	a.isDownloading = false
-	a.forceStop = true
+// ListDownloads 返回队列中所有任务
+func (a *App) ListDownloads() []downloader.Job {
+	return a.queue.Jobs()
+}
 
-	// 清空所有通道
-	a.clearAllChannels()
+// SetMaxConcurrentDownloads 设置同时进行的下载任务数
+func (a *App) SetMaxConcurrentDownloads(n int) {
+	a.queue.SetConcurrency(n)
+}
 
-	// 停止所有事件监听器
-	for name, stopFunc := range a.eventListeners {
-		if stopFunc != nil {
-			stopFunc()
-			log.Printf("[Backend] 停止监听器: %s", name)
-		}
-	}
-	a.eventListeners = make(map[string]func())
+// SetPageConcurrency 设置某个站点（域名）单个任务内同时下载的页数
+func (a *App) SetPageConcurrency(host string, n int) {
+	a.queue.SetPageWorkers(host, n)
 }
 
-func (a *App) cleanupDownloadState() {
-	a.downloadMutex.Lock()
-	defer a.downloadMutex.Unlock()
+// GetOutputFormats 返回可选的输出格式
+func (a *App) GetOutputFormats() []string {
+	return exporter.Formats()
+}
 
-	a.isDownloading = false
-	a.forceStop = false
+// ReleaseEpisode 释放搜索得到的章节，前端关闭结果时调用
+func (a *App) ReleaseEpisode(episodeID string) {
+	a.episodes.Remove(episodeID)
 }
 
-func (a *App) sendProgressSafely(progress DownloadProgress, sessionId int64) error {
-	// 检查会话是否有效
-	if a.shouldStopDownload(sessionId) {
-		log.Printf("[Backend] ❌ 会话 %d 已停止，不发送进度", sessionId)
-		return fmt.Errorf("会话已停止")
+// emitProgress 将任务状态推送给前端
+func (a *App) emitProgress(job downloader.Job) {
+	if a.ctx == nil {
+		return
 	}
-
-	// 检查进度停止通道
-	select {
-	case <-a.progressStopChan:
-		log.Println("[Backend] ❌ 收到停止信号，不发送进度")
-		a.setForceStop()
-		return fmt.Errorf("进度发送已停止")
-	default:
+	progress := DownloadProgress{
+		JobID:   job.ID,
+		Current: job.Current,
+		Total:   job.Total,
+		Title:   job.Title,
+		Status:  string(job.State),
+		Error:   job.Error,
 	}
+	log.Printf("[Backend] 📤 发送进度: %+v", progress)
+	runtime.EventsEmit(a.ctx, "download-progress", progress)
+}
 
-	// 检查上下文
-	if a.ctx == nil {
-		return fmt.Errorf("上下文无效")
+// dataURL 将图片编码为前端可直接显示的 data URL
+func dataURL(img *provider.Image) string {
+	mime := "image/" + img.Ext
+	if img.Ext == "jpg" {
+		mime = "image/jpeg"
 	}
-
-	// 发送进度
-	log.Printf("[Backend] 📤 发送进度: %+v [会话:%d]", progress, sessionId)
-	runtime.EventsEmit(a.ctx, "download-progress", progress)
-	return nil
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
 }
 
 func contains(s, substr string) bool {
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"mg-Downloader/pkg/cookies"
+	"mg-Downloader/pkg/provider"
+)
+
+// runCookies 处理 cookies 子命令
+func runCookies(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "import":
+			return runCookiesImport(args[1:])
+		case "browser":
+			return runCookiesBrowser(args[1:])
+		case "check":
+			return runCookiesCheck(ctx, args[1:])
+		}
+	}
+	return fmt.Errorf("用法: mg-downloader cookies import <文件|-> [--site 站点] [--format 格式]\n" +
+		"      mg-downloader cookies browser <浏览器配置目录|cookie 数据库> [--site 站点]\n" +
+		"      mg-downloader cookies check [--site 站点]")
+}
+
+// runCookiesImport 从文件或标准输入导入 cookie
+func runCookiesImport(args []string) error {
+	fs := flag.NewFlagSet("cookies import", flag.ExitOnError)
+	site := fs.String("site", "", "导入到哪个站点，默认按域名导入到所有站点")
+	format := fs.String("format", cookies.FormatAuto, "文件格式: "+strings.Join(cookies.Formats(), ", "))
+	sf := addSettingsFlags(fs)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		return fmt.Errorf("需要且只需要一个文件，- 表示从标准输入读取")
+	}
+	if _, err := sf.load(); err != nil {
+		return err
+	}
+
+	data, err := readInput(positional[0])
+	if err != nil {
+		return err
+	}
+	sites, err := cookieSites(*site)
+	if err != nil {
+		return err
+	}
+	result, err := cookies.ImportSites(data, *format, sites)
+	if err != nil {
+		return err
+	}
+	printImported(result)
+	return nil
+}
+
+// runCookiesBrowser 从 Firefox 或 Chromium 系浏览器的配置目录导入 cookie
+func runCookiesBrowser(args []string) error {
+	fs := flag.NewFlagSet("cookies browser", flag.ExitOnError)
+	site := fs.String("site", "", "导入到哪个站点，默认按域名导入到所有站点")
+	sf := addSettingsFlags(fs)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		return fmt.Errorf("需要且只需要一个浏览器配置目录或 cookie 数据库文件")
+	}
+	if _, err := sf.load(); err != nil {
+		return err
+	}
+
+	sites, err := cookieSites(*site)
+	if err != nil {
+		return err
+	}
+	result, err := cookies.ImportBrowser(positional[0], sites)
+	if err != nil {
+		return err
+	}
+	printImported(result)
+	return nil
+}
+
+// runCookiesCheck 检查各站点保存的 cookie 是否仍处于登录状态，有站点未登录时返回错误
+func runCookiesCheck(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("cookies check", flag.ExitOnError)
+	site := fs.String("site", "", "只检查这个站点，默认检查所有使用 cookie 的站点")
+	sf := addSettingsFlags(fs)
+	if positional := parseArgs(fs, args); len(positional) != 0 {
+		return fmt.Errorf("cookies check 不需要参数")
+	}
+	if _, err := sf.load(); err != nil {
+		return err
+	}
+
+	sites, err := cookieSites(*site)
+	if err != nil {
+		return err
+	}
+	if sites == nil {
+		for _, p := range provider.List() {
+			if _, ok := p.(provider.LoginChecker); ok {
+				sites = append(sites, p)
+			}
+		}
+	}
+
+	failed := 0
+	for _, p := range sites {
+		status, err := provider.CheckLogin(ctx, p)
+		switch {
+		case err != nil && !provider.IsLoginError(err):
+			failed++
+			fmt.Printf("%s: 检查失败: %v\n", p.Name(), err)
+		case !status.LoggedIn:
+			failed++
+			fmt.Printf("%s: %s\n", p.Name(), status.Reason)
+		default:
+			line := p.Name() + ": 已登录"
+			if status.User != "" {
+				line += "，用户 " + status.User
+			}
+			if !status.Expires.IsZero() {
+				line += "，cookie 有效期至 " + status.Expires.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Println(line)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个站点未登录", failed)
+	}
+	return nil
+}
+
+// cookieSites 返回 --site 指定的站点，未指定时返回 nil 表示所有站点
+func cookieSites(name string) ([]provider.Provider, error) {
+	if name == "" {
+		return nil, nil
+	}
+	p, err := provider.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return []provider.Provider{p}, nil
+}
+
+func printImported(result map[string]int) {
+	names := make([]string, 0, len(result))
+	for name := range result {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s: 导入 %d 条 cookie\n", name, result[name])
+	}
+}
+
+// readInput 读取文件，"-" 表示标准输入
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// 没有在命令行中指定时从这些环境变量读取用户名和密码，便于在 CI 中使用
+const (
+	envUser     = "MG_DOWNLOADER_USER"
+	envPassword = "MG_DOWNLOADER_PASSWORD"
+)
+
+// runLogin 用用户名和密码登录站点，登录 cookie 保存到站点的 cookie 文件
+func runLogin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	site := fs.String("site", "", "登录的站点")
+	user := fs.String("user", os.Getenv(envUser), "用户名（邮箱），默认读取环境变量 "+envUser)
+	passwordStdin := fs.Bool("password-stdin", false, "从标准输入读取密码，默认读取环境变量 "+envPassword)
+	sf := addSettingsFlags(fs)
+	if positional := parseArgs(fs, args); len(positional) != 0 {
+		return fmt.Errorf("login 不需要参数")
+	}
+	if *site == "" {
+		return fmt.Errorf("需要用 --site 指定站点")
+	}
+	if _, err := sf.load(); err != nil {
+		return err
+	}
+	p, err := provider.Get(*site)
+	if err != nil {
+		return err
+	}
+
+	password := os.Getenv(envPassword)
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("读取密码失败: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if *user == "" || password == "" {
+		return fmt.Errorf("需要用户名和密码：使用 --user 和 --password-stdin，或设置 %s 和 %s", envUser, envPassword)
+	}
+
+	status, err := provider.Login(ctx, p, *user, password)
+	if err != nil {
+		return err
+	}
+	line := p.Name() + ": 登录成功"
+	if status.User != "" {
+		line += "，用户 " + status.User
+	}
+	if !status.Expires.IsZero() {
+		line += "，cookie 有效期至 " + status.Expires.Local().Format("2006-01-02 15:04")
+	}
+	fmt.Println(line)
+	return nil
+}
@@ -0,0 +1,330 @@
+// mg-downloader 是不依赖图形界面的命令行下载器，与 GUI 共用站点和下载实现。
+//
+// 子命令和参数见 usage，不带参数运行时打印。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"mg-Downloader/pkg/downloader"
+	"mg-Downloader/pkg/exporter"
+	"mg-Downloader/pkg/pathtmpl"
+	"mg-Downloader/pkg/provider"
+	"mg-Downloader/pkg/selection"
+
+	_ "mg-Downloader/pkg/comicDays"
+	_ "mg-Downloader/pkg/ourfeel"
+	_ "mg-Downloader/pkg/pocketShonenmagazine"
+)
+
+const usage = `用法:
+  mg-downloader get <url> [-o 目录] [--format images|cbz|epub|pdf] [--ltr] [-p 页码] [-t 路径模板] [-j 并行页数] [--mode 站点]
+  mg-downloader export <目录> --format cbz|epub|pdf [--ltr] [-o 文件]
+  mg-downloader series <url> [-e 章节选择] [-o 目录] [--format 格式] [--ltr] [-t 路径模板] [--mode 站点]
+  mg-downloader info <url> [--mode 站点]
+  mg-downloader cookies import <文件|-> [--site 站点] [--format auto|json|netscape|header|har]
+  mg-downloader cookies browser <浏览器配置目录|cookie 数据库> [--site 站点]
+  mg-downloader cookies check [--site 站点]
+  mg-downloader login --site 站点 [--user 用户名] [--password-stdin]
+  mg-downloader sites
+
+cookies import 支持 Cookie-Editor 导出的 JSON、Netscape cookies.txt、HAR 文件和 Cookie 请求头，
+按域名合并到各站点的 cookie 文件；导入 Cookie 请求头时需要用 --site 指定站点。
+cookies browser 直接读取 Firefox 的 cookies.sqlite 或 Chromium 系浏览器未加密的 Cookies 数据库。
+cookies check 检查保存的 cookie 是否仍处于登录状态，有站点未登录时以非零状态退出。
+login 用用户名和密码登录 comicDays、ourfeel，登录 cookie 保存到站点的 cookie 文件；
+没有指定时从环境变量 MG_DOWNLOADER_USER、MG_DOWNLOADER_PASSWORD 读取用户名和密码。
+
+get、series、info 和 cookies 都读取设置文件（--config 指定路径），--timeout、--retries、--cookies、
+--proxy、--format、-t、-j 在命令行中指定时优先于设置文件。
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "get":
+		err = runGet(ctx, os.Args[2:])
+	case "export":
+		err = runExport(os.Args[2:])
+	case "series":
+		err = runSeries(ctx, os.Args[2:])
+	case "info":
+		err = runInfo(ctx, os.Args[2:])
+	case "cookies":
+		err = runCookies(ctx, os.Args[2:])
+	case "login":
+		err = runLogin(ctx, os.Args[2:])
+	case "sites":
+		for _, p := range provider.List() {
+			fmt.Println(p.Name())
+		}
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		if provider.IsLoginError(err) {
+			fmt.Fprintln(os.Stderr, "可以用 mg-downloader login 登录，或用 cookies import、cookies browser 重新导入 cookie")
+		}
+		os.Exit(1)
+	}
+}
+
+func runGet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	pagesExpr := fs.String("p", "", "只下载部分页，如 1-5,20-，默认下载整话")
+	mode := fs.String("mode", "", "指定站点，默认根据链接自动识别")
+	dl := addDownloadFlags(fs)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		return fmt.Errorf("需要且只需要一个链接")
+	}
+	if err := dl.load(); err != nil {
+		return err
+	}
+
+	var pages []selection.Range
+	if *pagesExpr != "" {
+		var err error
+		if pages, err = selection.ParseRanges(*pagesExpr); err != nil {
+			return err
+		}
+	}
+	tmpl, err := pathtmpl.Parse(*dl.template)
+	if err != nil {
+		return err
+	}
+
+	p, ep, err := fetchEpisode(ctx, *mode, positional[0])
+	if err != nil {
+		return err
+	}
+
+	// 散图默认放进书库目录下每一话的文件夹，打包格式和带文件夹的模板直接生成在书库目录
+	dir := *dl.outDir
+	if dir == "" {
+		dir = downloader.EpisodeOutDir(dl.library, ep, *dl.format, tmpl)
+	}
+	fmt.Printf("%s (%d页) -> %s\n", ep.Title, len(ep.Pages), dir)
+
+	err = downloader.Download(ctx, p, ep, dir, downloader.Options{
+		Workers:  *dl.workers,
+		Format:   *dl.format,
+		Export:   dl.exportOptions(),
+		Pages:    pages,
+		Template: tmpl,
+		Progress: func(done, total int) {
+			fmt.Printf("进度: %d/%d\n", done, total)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println("下载完成")
+	return nil
+}
+
+// runExport 将已经下载好的散图目录打包
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "epub", "输出格式: "+strings.Join(exporter.Formats()[1:], ", "))
+	out := fs.String("o", "", "输出文件，默认为目录名加扩展名")
+	ltr := fs.Bool("ltr", false, "按从左向右翻页，默认从右向左")
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		return fmt.Errorf("需要且只需要一个目录")
+	}
+
+	exp, err := exporter.Get(*format)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Clean(positional[0])
+	dst := *out
+	if dst == "" {
+		dst = dir + "." + exp.Ext()
+	}
+	if err := downloader.Export(dir, *format, dst, exporter.Options{LeftToRight: *ltr}); err != nil {
+		return err
+	}
+	fmt.Println("已生成", dst)
+	return nil
+}
+
+// runSeries 列出作品的所有章节，指定 -e 时依次下载选中的章节
+func runSeries(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("series", flag.ExitOnError)
+	expr := fs.String("e", "", "要下载的章节，如 1-10,15,latest:3,free；不指定时只列出章节")
+	mode := fs.String("mode", "", "指定站点，默认根据链接自动识别")
+	dl := addDownloadFlags(fs)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		return fmt.Errorf("需要且只需要一个链接")
+	}
+	if err := dl.load(); err != nil {
+		return err
+	}
+	tmpl, err := pathtmpl.Parse(*dl.template)
+	if err != nil {
+		return err
+	}
+
+	sp, err := resolveSeries(*mode, positional[0])
+	if err != nil {
+		return err
+	}
+	series, err := sp.FetchSeries(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if *expr == "" {
+		fmt.Printf("%s (%d话)\n", series.Title, len(series.Episodes))
+		for _, e := range series.Episodes {
+			status := "付费"
+			if e.Free {
+				status = "免费"
+			}
+			date := ""
+			if !e.PublishedAt.IsZero() {
+				date = e.PublishedAt.Format("2006-01-02")
+			}
+			fmt.Printf("%4d  %-10s  %s  %s\n", e.Number, date, status, e.Title)
+		}
+		return nil
+	}
+
+	sel, err := selection.ParseEpisodes(*expr)
+	if err != nil {
+		return err
+	}
+	selected := sel.Apply(series.Episodes)
+	if len(selected) == 0 {
+		return fmt.Errorf("没有符合选择的章节")
+	}
+
+	outDir := *dl.outDir
+	if outDir == "" {
+		outDir = dl.library
+	}
+
+	failed := 0
+	for i, e := range selected {
+		fmt.Printf("[%d/%d] 第%d话 %s\n", i+1, len(selected), e.Number, e.Title)
+		dir := downloader.BatchOutDir(outDir, e, *dl.format, tmpl)
+		err := downloadEpisode(ctx, sp, e, dir, downloader.Options{Workers: *dl.workers, Format: *dl.format, Export: dl.exportOptions(), Template: tmpl})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 话下载失败，可重新执行以继续", failed)
+	}
+	fmt.Println("下载完成")
+	return nil
+}
+
+// downloadEpisode 下载作品中的一话
+func downloadEpisode(ctx context.Context, p provider.Provider, e provider.SeriesEpisode, dir string, opts downloader.Options) error {
+	ep, err := p.FetchEpisode(ctx, e.URL)
+	if err != nil {
+		return err
+	}
+	opts.Progress = func(done, total int) {
+		fmt.Printf("进度: %d/%d\n", done, total)
+	}
+	return downloader.Download(ctx, p, ep, dir, opts)
+}
+
+func runInfo(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	mode := fs.String("mode", "", "指定站点，默认根据链接自动识别")
+	sf := addSettingsFlags(fs)
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		return fmt.Errorf("需要且只需要一个链接")
+	}
+	if _, err := sf.load(); err != nil {
+		return err
+	}
+
+	p, ep, err := fetchEpisode(ctx, *mode, positional[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("站点: %s\n标题: %s\n章节ID: %s\n页数: %d\n", p.Name(), ep.Title, ep.ID, len(ep.Pages))
+	return nil
+}
+
+// fetchEpisode 按 mode 或链接找到站点并获取章节
+func fetchEpisode(ctx context.Context, mode, rawURL string) (provider.Provider, *provider.Episode, error) {
+	var p provider.Provider
+	var err error
+	if mode != "" {
+		p, err = provider.Get(mode)
+	} else {
+		p, err = provider.Resolve(rawURL)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ep, err := p.FetchEpisode(ctx, rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, ep, nil
+}
+
+// resolveSeries 按 mode 或链接找到能列出章节的站点
+func resolveSeries(mode, rawURL string) (provider.SeriesProvider, error) {
+	if mode == "" {
+		return provider.ResolveSeries(rawURL)
+	}
+	p, err := provider.Get(mode)
+	if err != nil {
+		return nil, err
+	}
+	sp, ok := p.(provider.SeriesProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s 不支持列出作品章节", mode)
+	}
+	return sp, nil
+}
+
+// parseArgs 允许参数和选项混排，例如 get <url> -o dir
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"mg-Downloader/pkg/config"
+	"mg-Downloader/pkg/exporter"
+)
+
+// settingsFlags 与设置文件对应的选项，命令行中指定的值优先于设置文件
+type settingsFlags struct {
+	path    *string
+	timeout *time.Duration
+	retries *int
+	cookies *string
+	proxy   *string
+}
+
+func addSettingsFlags(fs *flag.FlagSet) *settingsFlags {
+	return &settingsFlags{
+		path:    fs.String("config", config.DefaultPath(), "设置文件路径"),
+		timeout: fs.Duration("timeout", 0, "单次请求的超时时间，如 30s，默认使用设置文件"),
+		retries: fs.Int("retries", -1, "请求失败时的最大重试次数，0 表示不重试，默认使用设置文件"),
+		cookies: fs.String("cookies", "", "cookie 文件路径，默认使用设置文件"),
+		proxy:   fs.String("proxy", "", "代理地址，如 socks5://127.0.0.1:1080，direct 表示直连，默认使用设置文件或环境变量"),
+	}
+}
+
+// load 读取设置文件，叠加命令行选项后应用到各站点
+func (f *settingsFlags) load() (*config.Settings, error) {
+	settings, err := config.Load(*f.path)
+	if err != nil {
+		return nil, err
+	}
+	for name, site := range settings.Sites {
+		if *f.timeout > 0 {
+			site.Timeout = config.Duration(*f.timeout)
+		}
+		if *f.retries >= 0 {
+			retries := *f.retries
+			site.MaxRetries = &retries
+		}
+		if *f.cookies != "" {
+			site.CookieFile = *f.cookies
+		}
+		if *f.proxy != "" {
+			site.Proxy = *f.proxy
+		}
+		settings.Sites[name] = site
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	settings.Apply()
+	return settings, nil
+}
+
+// downloadFlags get 和 series 共用的下载选项，未指定时使用设置文件中的默认值
+type downloadFlags struct {
+	*settingsFlags
+	outDir   *string
+	format   *string
+	template *string
+	workers  *int
+	ltr      *bool
+	// library 没有指定 -o 时的输出目录：设置中的书库目录或当前目录
+	library string
+}
+
+// exportOptions 打包选项
+func (f *downloadFlags) exportOptions() exporter.Options {
+	return exporter.Options{LeftToRight: *f.ltr}
+}
+
+func addDownloadFlags(fs *flag.FlagSet) *downloadFlags {
+	return &downloadFlags{
+		settingsFlags: addSettingsFlags(fs),
+		outDir:        fs.String("o", "", "输出目录，默认为设置中的书库目录，未设置时为当前目录"),
+		format:        fs.String("format", "", "输出格式: "+strings.Join(exporter.Formats(), ", ")+"，默认使用设置文件或 images"),
+		template:      fs.String("t", "", "输出路径模板，如 \"{series}/{episode_number:03} {episode_title}/{page:03}.{ext}\""),
+		workers:       fs.Int("j", 0, "并行下载的页数，默认使用设置文件"),
+		ltr:           fs.Bool("ltr", false, "打包时按从左向右翻页，默认从右向左"),
+	}
+}
+
+// load 读取设置并补全未指定的下载选项
+func (f *downloadFlags) load() error {
+	settings, err := f.settingsFlags.load()
+	if err != nil {
+		return err
+	}
+	if *f.format == "" {
+		*f.format = settings.Downloads.Format
+	}
+	if *f.format == "" {
+		*f.format = exporter.FormatImages
+	}
+	if *f.template == "" {
+		*f.template = settings.Downloads.Template
+	}
+	f.library = settings.Downloads.LibraryDir
+	if f.library == "" {
+		f.library = "."
+	}
+	if *f.workers < 1 {
+		*f.workers = settings.Downloads.PageWorkers
+	}
+	return nil
+}
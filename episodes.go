@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// episodeEntry 一次搜索得到的章节及其所属站点
+type episodeEntry struct {
+	Provider provider.Provider
+	Episode  *provider.Episode
+}
+
+// episodeRegistry 保存已搜索的章节，按ID索引，多个章节可以同时存在
+type episodeRegistry struct {
+	mu      sync.RWMutex
+	nextID  int64
+	entries map[string]*episodeEntry
+}
+
+func newEpisodeRegistry() *episodeRegistry {
+	return &episodeRegistry{
+		entries: make(map[string]*episodeEntry),
+	}
+}
+
+// Add 保存章节并返回其ID
+func (r *episodeRegistry) Add(p provider.Provider, ep *provider.Episode) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := strconv.FormatInt(r.nextID, 10)
+	r.entries[id] = &episodeEntry{Provider: p, Episode: ep}
+	return id
+}
+
+// Get 按ID取出章节
+func (r *episodeRegistry) Get(id string) (*episodeEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.entries[id]
+	if !ok {
+		return nil, fmt.Errorf("章节不存在或已过期，请重新搜索")
+	}
+	return entry, nil
+}
+
+// Remove 释放不再需要的章节
+func (r *episodeRegistry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, id)
+}
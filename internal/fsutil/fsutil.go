@@ -0,0 +1,41 @@
+// Package fsutil 文件写入的公共工具
+package fsutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomic 通过 write 写入同目录下的临时文件，成功后设置权限并改名为 path，
+// 中断或失败时不会留下写了一半的文件。临时文件名唯一，同时写同一个文件时互不影响，
+// 最后改名的那次生效。
+func WriteAtomic(path string, perm os.FileMode, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// WriteFile 以 WriteAtomic 的方式写入 data
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return WriteAtomic(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
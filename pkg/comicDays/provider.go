@@ -0,0 +1,14 @@
+// Package comicDays registers comic-days.com, a GigaViewer site run by Kodansha.
+package comicDays
+
+import "mg-Downloader/pkg/gigaviewer"
+
+func init() {
+	gigaviewer.Register(gigaviewer.Site{
+		Name:       "comicDays",
+		BaseURL:    "https://comic-days.com",
+		Host:       "comic-days.com",
+		CookieFile: "./cookies/cookie.cd.json",
+		Publisher:  "講談社",
+	})
+}
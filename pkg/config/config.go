@@ -0,0 +1,228 @@
+// Package config 读写持久化的设置文件，GUI 和命令行共用
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"mg-Downloader/internal/fsutil"
+	"mg-Downloader/pkg/exporter"
+	"mg-Downloader/pkg/httpx"
+	"mg-Downloader/pkg/pathtmpl"
+	"mg-Downloader/pkg/provider"
+)
+
+// FileName 设置文件的文件名
+const FileName = "settings.json"
+
+// Duration 以 "15s"、"500ms" 这样的字符串保存的时间间隔
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		// 也接受以秒为单位的数字
+		var seconds float64
+		if err := json.Unmarshal(data, &seconds); err != nil {
+			return fmt.Errorf("无效的时间: %s", data)
+		}
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("无效的时间: %s", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Site 单个站点的设置，留空的字段使用站点默认值。
+// MaxRetries、RateLimit、MaxConns 设为 0 表示不重试、不限制频率、不限制连接数，
+// 所以用指针区分 0 和未设置。
+type Site struct {
+	Timeout    Duration `json:"timeout,omitempty"`
+	MaxRetries *int     `json:"max_retries,omitempty"`
+	RetryDelay Duration `json:"retry_delay,omitempty"`
+	RateLimit  *float64 `json:"rate_limit,omitempty"`
+	MaxConns   *int     `json:"max_conns,omitempty"`
+	TileCount  int      `json:"tile_count,omitempty"`
+	CookieFile string   `json:"cookie_file,omitempty"`
+	Proxy      string   `json:"proxy,omitempty"`
+}
+
+// Downloads 下载队列的设置
+type Downloads struct {
+	// MaxConcurrent 同时进行的下载任务数
+	MaxConcurrent int `json:"max_concurrent"`
+	// PageWorkers 单个任务内并行下载的页数
+	PageWorkers int `json:"page_workers"`
+	// Format 默认输出格式
+	Format string `json:"format,omitempty"`
+	// Template 默认输出路径模板
+	Template string `json:"template,omitempty"`
+	// LibraryDir 书库目录，下载时没有指定保存路径则保存到这里
+	LibraryDir string `json:"library_dir,omitempty"`
+}
+
+// Settings 设置文件的内容
+type Settings struct {
+	Downloads Downloads `json:"downloads"`
+	// Sites 按站点名称（与 mode 一致）保存的设置
+	Sites map[string]Site `json:"sites"`
+}
+
+// DefaultPath 设置文件的默认位置，Linux 上为 $XDG_CONFIG_HOME/mg-Downloader/settings.json
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".", "data", FileName)
+	}
+	return filepath.Join(dir, "mg-Downloader", FileName)
+}
+
+// Default 返回默认设置，站点部分取自各站点的默认参数
+func Default() *Settings {
+	s := &Settings{
+		Downloads: Downloads{MaxConcurrent: 2, PageWorkers: 4},
+		Sites:     make(map[string]Site),
+	}
+	s.Normalize()
+	return s
+}
+
+// Load 读取设置文件，文件不存在时返回默认设置；缺少的字段用默认值补全
+func Load(path string) (*Settings, error) {
+	s := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("读取设置失败: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return Default(), fmt.Errorf("解析设置失败: %w", err)
+	}
+	s.Normalize()
+	if err := s.Validate(); err != nil {
+		return Default(), fmt.Errorf("设置无效: %w", err)
+	}
+	return s, nil
+}
+
+// Save 将设置写入 path，先写临时文件再改名
+func (s *Settings) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化设置失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建设置目录失败: %w", err)
+	}
+	if err := fsutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存设置失败: %w", err)
+	}
+	return nil
+}
+
+// Apply 将站点设置应用到已注册的站点
+func (s *Settings) Apply() {
+	for _, p := range provider.List() {
+		if c, ok := p.(provider.Configurable); ok {
+			c.Configure(s.Sites[p.Name()].provider())
+		}
+	}
+}
+
+// Validate 检查默认输出格式、路径模板和各站点的代理设置是否有效
+func (s *Settings) Validate() error {
+	if f := s.Downloads.Format; f != "" && f != exporter.FormatImages {
+		if _, err := exporter.Get(f); err != nil {
+			return err
+		}
+	}
+	if _, err := pathtmpl.Parse(s.Downloads.Template); err != nil {
+		return err
+	}
+	for name, site := range s.Sites {
+		if err := httpx.ValidateProxy(site.Proxy); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Normalize 用站点默认参数补全未设置的字段，便于在界面中显示和修改
+func (s *Settings) Normalize() {
+	if s.Sites == nil {
+		s.Sites = make(map[string]Site)
+	}
+	if s.Downloads.MaxConcurrent < 1 {
+		s.Downloads.MaxConcurrent = 2
+	}
+	if s.Downloads.PageWorkers < 1 {
+		s.Downloads.PageWorkers = 4
+	}
+	for _, p := range provider.List() {
+		c, ok := p.(provider.Configurable)
+		if !ok {
+			continue
+		}
+		site := s.Sites[p.Name()].provider().Or(c.DefaultSettings())
+		s.Sites[p.Name()] = fromProvider(site)
+	}
+}
+
+func (s Site) provider() provider.Settings {
+	return provider.Settings{
+		Timeout:    time.Duration(s.Timeout),
+		MaxRetries: toProvider(s.MaxRetries),
+		RetryDelay: time.Duration(s.RetryDelay),
+		RateLimit:  toProvider(s.RateLimit),
+		MaxConns:   toProvider(s.MaxConns),
+		TileCount:  s.TileCount,
+		CookieFile: s.CookieFile,
+		Proxy:      s.Proxy,
+	}
+}
+
+func fromProvider(s provider.Settings) Site {
+	return Site{
+		Timeout:    Duration(s.Timeout),
+		MaxRetries: fromSetting(s.MaxRetries),
+		RetryDelay: Duration(s.RetryDelay),
+		RateLimit:  fromSetting(s.RateLimit),
+		MaxConns:   fromSetting(s.MaxConns),
+		TileCount:  s.TileCount,
+		CookieFile: s.CookieFile,
+		Proxy:      s.Proxy,
+	}
+}
+
+// toProvider 将可以设为 0 的字段转换为站点参数：未设置为 0（使用默认值），0 为 -1（关闭）
+func toProvider[T int | float64](v *T) T {
+	switch {
+	case v == nil:
+		return 0
+	case *v <= 0:
+		return -1
+	}
+	return *v
+}
+
+// fromSetting 是 toProvider 的逆转换，关闭的参数保存为 0
+func fromSetting[T int | float64](v T) *T {
+	if v < 0 {
+		v = 0
+	}
+	return &v
+}
@@ -0,0 +1,237 @@
+package cookies
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mg-Downloader/pkg/sqlite"
+)
+
+// 支持读取的浏览器
+const (
+	BrowserFirefox  = "firefox"
+	BrowserChromium = "chromium"
+)
+
+// chromiumEpoch 1601-01-01 与 Unix 时间起点相差的秒数，Chromium 的时间从 1601-01-01 开始，以微秒计
+const chromiumEpoch = 11644473600
+
+// FindBrowserDB 根据浏览器配置目录或数据库文件找到 cookie 数据库，并判断浏览器类型。
+// Firefox 的配置目录中是 cookies.sqlite，Chromium 系浏览器是 Cookies 或 Network/Cookies。
+func FindBrowserDB(profile string) (string, string, error) {
+	info, err := os.Stat(profile)
+	if err != nil {
+		return "", "", fmt.Errorf("找不到浏览器配置: %w", err)
+	}
+	if !info.IsDir() {
+		if strings.EqualFold(filepath.Base(profile), "cookies.sqlite") {
+			return profile, BrowserFirefox, nil
+		}
+		return profile, BrowserChromium, nil
+	}
+
+	candidates := []struct{ path, browser string }{
+		{filepath.Join(profile, "cookies.sqlite"), BrowserFirefox},
+		{filepath.Join(profile, "Network", "Cookies"), BrowserChromium},
+		{filepath.Join(profile, "Cookies"), BrowserChromium},
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c.path); err == nil {
+			return c.path, c.browser, nil
+		}
+	}
+	return "", "", fmt.Errorf("%s 中没有找到 cookies.sqlite 或 Cookies", profile)
+}
+
+// ReadBrowser 读取浏览器配置目录或 cookie 数据库中的全部 cookie，返回浏览器类型。
+// 只读取文件，浏览器运行时也可以使用。
+func ReadBrowser(profile string) ([]Cookie, string, error) {
+	path, browser, err := FindBrowserDB(profile)
+	if err != nil {
+		return nil, "", err
+	}
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var list []Cookie
+	switch browser {
+	case BrowserFirefox:
+		list, err = readFirefox(db)
+	default:
+		list, err = readChromium(db)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return list, browser, nil
+}
+
+// readFirefox 读取 Firefox 的 moz_cookies 表
+func readFirefox(db *sqlite.DB) ([]Cookie, error) {
+	columns := []string{"host", "name", "value", "path", "expiry", "isSecure", "isHttpOnly"}
+	var list []Cookie
+	err := db.Select("moz_cookies", columns, func(v []any) error {
+		host := text(v[0])
+		c := Cookie{
+			Domain:   host,
+			HostOnly: !strings.HasPrefix(host, "."),
+			Name:     text(v[1]),
+			Value:    text(v[2]),
+			Path:     text(v[3]),
+			Secure:   integer(v[5]) != 0,
+			HTTPOnly: integer(v[6]) != 0,
+		}
+		expiry := integer(v[4])
+		// 新版本 Firefox 以毫秒保存过期时间
+		if expiry > 1e11 {
+			expiry /= 1000
+		}
+		if expiry > 0 {
+			c.ExpirationDate = float64(expiry)
+		} else {
+			c.Session = true
+		}
+		list = append(list, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取 Firefox cookie 失败: %w", err)
+	}
+	return list, nil
+}
+
+// readChromium 读取 Chromium 系浏览器的 cookies 表。
+// 只支持未加密的值，以及 Linux 上没有系统钥匙串时使用固定密钥加密的 v10 值。
+func readChromium(db *sqlite.DB) ([]Cookie, error) {
+	table, err := db.Table("cookies")
+	if err != nil {
+		return nil, fmt.Errorf("读取 Chromium cookie 失败: %w", err)
+	}
+	secure, httpOnly := "is_secure", "is_httponly"
+	if !table.Has(secure) {
+		// 旧版本的列名
+		secure, httpOnly = "secure", "httponly"
+	}
+	columns := []string{"host_key", "name", "value", "encrypted_value", "path", "expires_utc", secure, httpOnly}
+
+	// 数据库版本 24 起解密后的值前面带有域名的 SHA-256
+	hashPrefix := chromiumVersion(db) >= 24
+
+	var list []Cookie
+	skipped := 0
+	err = db.Select("cookies", columns, func(v []any) error {
+		host := text(v[0])
+		value := text(v[2])
+		if value == "" {
+			if enc, _ := v[3].([]byte); len(enc) > 0 {
+				plain, err := decryptChromium(enc, hashPrefix)
+				if err != nil {
+					skipped++
+					return nil
+				}
+				value = plain
+			}
+		}
+
+		c := Cookie{
+			Domain:   host,
+			HostOnly: !strings.HasPrefix(host, "."),
+			Name:     text(v[1]),
+			Value:    value,
+			Path:     text(v[4]),
+			Secure:   integer(v[6]) != 0,
+			HTTPOnly: integer(v[7]) != 0,
+		}
+		if expires := integer(v[5]); expires > 0 {
+			c.ExpirationDate = float64(expires/1e6 - chromiumEpoch)
+		} else {
+			c.Session = true
+		}
+		list = append(list, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取 Chromium cookie 失败: %w", err)
+	}
+	if len(list) == 0 && skipped > 0 {
+		return nil, fmt.Errorf("%d 条 cookie 使用系统钥匙串加密，无法读取", skipped)
+	}
+	return list, nil
+}
+
+// chromiumVersion 读取 meta 表中的数据库版本，读取失败时返回 0
+func chromiumVersion(db *sqlite.DB) int {
+	version := 0
+	db.Select("meta", []string{"key", "value"}, func(v []any) error {
+		if text(v[0]) == "version" {
+			version, _ = strconv.Atoi(text(v[1]))
+		}
+		return nil
+	})
+	return version
+}
+
+// decryptChromium 解密 Linux 上的 v10 值：没有系统钥匙串时 Chromium 使用固定的密码 "peanuts"
+func decryptChromium(enc []byte, hashPrefix bool) (string, error) {
+	if !bytes.HasPrefix(enc, []byte("v10")) {
+		return "", errors.New("不支持的加密方式")
+	}
+	enc = enc[3:]
+	if len(enc) == 0 || len(enc)%aes.BlockSize != 0 {
+		return "", errors.New("加密数据长度错误")
+	}
+	key, err := pbkdf2.Key(sha1.New, "peanuts", []byte("saltysalt"), 1, 16)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	iv := bytes.Repeat([]byte{' '}, aes.BlockSize)
+	plain := make([]byte, len(enc))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, enc)
+
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(plain) {
+		return "", errors.New("解密失败")
+	}
+	plain = plain[:len(plain)-pad]
+	if hashPrefix {
+		if len(plain) < 32 {
+			return "", errors.New("解密失败")
+		}
+		plain = plain[32:]
+	}
+	return string(plain), nil
+}
+
+func text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+func integer(v any) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,130 @@
+package cookies
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// 测试用的浏览器数据库由 testdata/gen.py 生成
+
+func TestReadBrowserFirefox(t *testing.T) {
+	want := []Cookie{
+		{Domain: ".comic-days.com", Path: "/", Secure: true, HTTPOnly: true, Name: "glsc", Value: "firefox-token", ExpirationDate: farFuture},
+		// 以毫秒保存的过期时间
+		{Domain: "comic-days.com", HostOnly: true, Path: "/episode", Name: "viewer", Value: "1", ExpirationDate: farFuture},
+		{Domain: ".ourfeel.jp", Path: "/", Secure: true, HTTPOnly: true, Name: "glsc", Value: "session", Session: true},
+		{Domain: ".comic-days.com", Path: "/", Name: "old", Value: "expired", ExpirationDate: 1000000000},
+		{Domain: ".example.com", Path: "/", Name: "other", Value: "x", ExpirationDate: farFuture},
+	}
+	// 配置目录和数据库文件都可以
+	for _, profile := range []string{filepath.Join("testdata", "firefox"), filepath.Join("testdata", "firefox", "cookies.sqlite")} {
+		got, browser, err := ReadBrowser(profile)
+		if err != nil {
+			t.Fatalf("%s: %v", profile, err)
+		}
+		if browser != BrowserFirefox {
+			t.Errorf("%s: browser = %s, want %s", profile, browser, BrowserFirefox)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got\n%+v\nwant\n%+v", profile, got, want)
+		}
+	}
+}
+
+func TestReadBrowserChromium(t *testing.T) {
+	got, browser, err := ReadBrowser(filepath.Join("testdata", "chromium"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if browser != BrowserChromium {
+		t.Errorf("browser = %s, want %s", browser, BrowserChromium)
+	}
+	// 使用系统钥匙串加密的 ourfeel.jp 被跳过
+	want := []Cookie{
+		{Domain: ".comic-days.com", Path: "/", Secure: true, HTTPOnly: true, Name: "glsc", Value: "chromium-token", ExpirationDate: farFuture},
+		{Domain: "comic-days.com", HostOnly: true, Path: "/episode", Name: "viewer", Value: "1", Session: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestReadBrowserChromiumLegacy(t *testing.T) {
+	got, _, err := ReadBrowser(filepath.Join("testdata", "chromium-legacy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cookie{
+		{Domain: ".comic-days.com", Path: "/", Secure: true, HTTPOnly: true, Name: "glsc", Value: "legacy-token", ExpirationDate: farFuture},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestReadBrowserErrors(t *testing.T) {
+	empty := t.TempDir()
+	notDB := filepath.Join(t.TempDir(), "Cookies")
+	if err := os.WriteFile(notDB, []byte(strings.Repeat("not a database", 20)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{filepath.Join("testdata", "chromium-keyring"), "钥匙串"},
+		{filepath.Join("testdata", "missing"), "找不到浏览器配置"},
+		{empty, "没有找到"},
+		{notDB, ""},
+	}
+	for _, tt := range tests {
+		_, _, err := ReadBrowser(tt.profile)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ReadBrowser(%s) = %v, want an error containing %q", tt.profile, err, tt.want)
+		}
+	}
+}
+
+func TestImportBrowser(t *testing.T) {
+	dir := t.TempDir()
+	cd := &cookieSite{"comicDays", []string{"comic-days.com"}, filepath.Join(dir, "cookie.cd.json")}
+	of := &cookieSite{"ourfeel", []string{"ourfeel.jp"}, filepath.Join(dir, "cookie.of.json")}
+
+	got, err := ImportBrowser(filepath.Join("testdata", "firefox"), []provider.Provider{cd, of})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 过期的 old 和其他站点的 cookie 不导入
+	if want := map[string]int{"comicDays": 2, "ourfeel": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("imported %v, want %v", got, want)
+	}
+
+	data, err := os.ReadFile(cd.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := decodeFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "glsc" || list[0].Value != "firefox-token" || list[1].Name != "viewer" {
+		t.Errorf("saved %+v", list)
+	}
+
+	// Chromium 中刷新的登录 cookie 替换之前导入的
+	if _, err := ImportBrowser(filepath.Join("testdata", "chromium", "Network", "Cookies"), []provider.Provider{cd}); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Shared(cd.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := store.Session("glsc"); err != nil || c.Value != "chromium-token" {
+		t.Errorf("Session(glsc) = %+v, %v", c, err)
+	}
+}
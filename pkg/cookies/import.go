@@ -0,0 +1,302 @@
+package cookies
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// 可以导入的格式
+const (
+	// FormatAuto 根据内容自动判断
+	FormatAuto = "auto"
+	// FormatJSON Cookie-Editor 插件导出的 JSON 数组，即 cookie 文件本身的格式
+	FormatJSON = "json"
+	// FormatNetscape Netscape cookies.txt，yt-dlp、curl 等工具使用的格式
+	FormatNetscape = "netscape"
+	// FormatHeader 浏览器开发者工具中复制的 Cookie 请求头，如 "a=1; b=2"
+	FormatHeader = "header"
+	// FormatHAR 浏览器开发者工具导出的 HAR 文件
+	FormatHAR = "har"
+)
+
+// Formats 返回可以导入的格式
+func Formats() []string {
+	return []string{FormatAuto, FormatJSON, FormatNetscape, FormatHeader, FormatHAR}
+}
+
+// Parse 按 format 解析 data。Cookie 请求头中没有域名，使用 domain 作为域名。
+func Parse(data []byte, format, domain string) ([]Cookie, error) {
+	if format == "" || format == FormatAuto {
+		format = Detect(data)
+	}
+	switch format {
+	case FormatJSON:
+		list, err := decodeFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("解析 JSON cookie 失败: %w", err)
+		}
+		return list, nil
+	case FormatNetscape:
+		return parseNetscape(data)
+	case FormatHeader:
+		return parseHeader(string(data), domain)
+	case FormatHAR:
+		return parseHAR(data)
+	}
+	return nil, fmt.Errorf("不支持的 cookie 格式: %s，可选: %s", format, strings.Join(Formats(), ", "))
+}
+
+// Detect 根据内容判断格式
+func Detect(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return FormatJSON
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return FormatHAR
+	case bytes.HasPrefix(trimmed, []byte("# Netscape")), bytes.HasPrefix(trimmed, []byte("# HTTP Cookie File")),
+		bytes.Contains(trimmed, []byte("\t")):
+		return FormatNetscape
+	}
+	return FormatHeader
+}
+
+// FilterDomains 只保留属于 domains（或其子域名）的 cookie
+func FilterDomains(list []Cookie, domains []string) []Cookie {
+	var out []Cookie
+	for _, c := range list {
+		host := strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+		for _, d := range domains {
+			d = strings.ToLower(d)
+			if host == d || strings.HasSuffix(host, "."+d) {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Import 合并 list 到 Store，名称、域名和路径相同的 cookie 被替换，返回导入的条数。
+// 需要调用 Save 写入文件。
+func (s *Store) Import(list []Cookie) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	n := 0
+	for _, c := range list {
+		if c.Name == "" || c.Domain == "" || c.Expired(now) {
+			continue
+		}
+		if c.Path == "" {
+			c.Path = "/"
+		}
+		if i := s.index(c); i >= 0 {
+			s.cookies[i] = c
+		} else {
+			s.cookies = append(s.cookies, c)
+		}
+		s.jar.SetCookies(c.url(), []*http.Cookie{c.http()})
+		n++
+	}
+	if n > 0 {
+		s.dirty = true
+	}
+	return n
+}
+
+// importList 合并 list 到 cookie 文件 path 并保存，返回导入的条数
+func importList(path string, list []Cookie) (int, error) {
+	store, err := Shared(path)
+	if err != nil {
+		// 无法解析的旧文件直接被导入的内容替换
+		store.mu.Lock()
+		store.loadErr = nil
+		store.mu.Unlock()
+	}
+	n := store.Import(list)
+	if err := store.Save(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// parseNetscape 解析 cookies.txt：每行依次为域名、是否包含子域名、路径、secure、过期时间、名称、值，
+// 以 #HttpOnly_ 开头的行是 HttpOnly cookie
+func parseNetscape(data []byte) ([]Cookie, error) {
+	var list []Cookie
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			// 值为空时部分工具会省略最后一个制表符
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies.txt 第 %d 行格式错误", lineNo)
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies.txt 第 %d 行过期时间无效: %s", lineNo, fields[4])
+		}
+
+		c := Cookie{
+			Domain:   fields[0],
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HTTPOnly: httpOnly,
+			Session:  expires == 0,
+		}
+		if expires > 0 {
+			c.ExpirationDate = float64(expires)
+		}
+		if !c.HostOnly && !strings.HasPrefix(c.Domain, ".") {
+			c.Domain = "." + c.Domain
+		}
+		if c.HostOnly {
+			c.Domain = strings.TrimPrefix(c.Domain, ".")
+		}
+		list = append(list, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 cookies.txt 失败: %w", err)
+	}
+	return list, nil
+}
+
+// parseHeader 解析 "name=value; name2=value2"，可以带 "Cookie:" 前缀
+func parseHeader(header, domain string) ([]Cookie, error) {
+	if domain == "" {
+		return nil, fmt.Errorf("导入 Cookie 请求头需要指定站点")
+	}
+	header = strings.TrimSpace(header)
+	if len(header) >= 7 && strings.EqualFold(header[:7], "cookie:") {
+		header = strings.TrimSpace(header[7:])
+	}
+
+	var list []Cookie
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		list = append(list, Cookie{
+			Domain:  "." + strings.TrimPrefix(domain, "."),
+			Name:    name,
+			Value:   strings.TrimSpace(value),
+			Path:    "/",
+			Session: true,
+		})
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("Cookie 请求头中没有 cookie")
+	}
+	return list, nil
+}
+
+// harFile HAR 中与 cookie 有关的部分
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				URL     string      `json:"url"`
+				Cookies []harCookie `json:"cookies"`
+			} `json:"request"`
+			Response struct {
+				Cookies []harCookie `json:"cookies"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path"`
+	Domain   string `json:"domain"`
+	Expires  string `json:"expires"`
+	HTTPOnly bool   `json:"httpOnly"`
+	Secure   bool   `json:"secure"`
+}
+
+// parseHAR 收集 HAR 中请求携带和响应设置的 cookie，后出现的覆盖先出现的
+func parseHAR(data []byte) ([]Cookie, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("解析 HAR 失败: %w", err)
+	}
+
+	var list []Cookie
+	add := func(c Cookie) {
+		for i, old := range list {
+			if old.Name == c.Name && old.Domain == c.Domain && old.Path == c.Path {
+				list[i] = c
+				return
+			}
+		}
+		list = append(list, c)
+	}
+	for _, e := range har.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		// 请求中的 cookie 不带域名，按所在站点的主域名保存
+		site, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			site = host
+		}
+		for _, hc := range e.Request.Cookies {
+			add(Cookie{Domain: "." + site, Name: hc.Name, Value: hc.Value, Path: "/", Session: true})
+		}
+		for _, hc := range e.Response.Cookies {
+			c := Cookie{
+				Name:     hc.Name,
+				Value:    hc.Value,
+				Path:     hc.Path,
+				Secure:   hc.Secure,
+				HTTPOnly: hc.HTTPOnly,
+				Session:  true,
+			}
+			if hc.Domain != "" {
+				c.Domain = "." + strings.TrimPrefix(strings.ToLower(hc.Domain), ".")
+			} else {
+				c.Domain, c.HostOnly = host, true
+			}
+			if c.Path == "" {
+				c.Path = defaultPath(u.Path)
+			}
+			if t, err := time.Parse(time.RFC3339, hc.Expires); err == nil {
+				c.Session = false
+				c.ExpirationDate = float64(t.Unix())
+			}
+			add(c)
+		}
+	}
+	return list, nil
+}
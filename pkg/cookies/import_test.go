@@ -0,0 +1,217 @@
+package cookies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// 2100-01-01，测试数据中未过期 cookie 的过期时间
+const farFuture = 4102444800
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseNetscape(t *testing.T) {
+	got, err := Parse(readFixture(t, "cookies.txt"), FormatNetscape, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cookie{
+		{Domain: ".comic-days.com", Path: "/", Secure: true, HTTPOnly: true, Name: "glsc", Value: "session-token", ExpirationDate: farFuture},
+		{Domain: "comic-days.com", HostOnly: true, Path: "/episode", Name: "viewer", Value: "1", Session: true},
+		// 过期的 cookie 在导入时才跳过
+		{Domain: ".comic-days.com", Path: "/", Name: "old", Value: "expired", ExpirationDate: 1000000000},
+		{Domain: ".ourfeel.jp", Path: "/", Name: "empty", ExpirationDate: farFuture},
+		{Domain: "www.example.com", HostOnly: true, Path: "/", Name: "other", Value: "x", ExpirationDate: farFuture},
+		// 值为空时省略了最后一个制表符
+		{Domain: "pocket.shonenmagazine.com", HostOnly: true, Path: "/", Secure: true, Name: "trailing", ExpirationDate: farFuture},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d cookies:\n%+v\nwant:\n%+v", len(got), got, want)
+	}
+}
+
+func TestParseNetscapeInvalid(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    string
+	}{
+		{"malformed.txt", "第 3 行格式错误"},
+		{"bad-expiry.txt", "第 1 行过期时间无效"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(readFixture(t, tt.fixture), FormatAuto, "")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.fixture, err, tt.want)
+		}
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	got, err := Parse([]byte("Cookie: glsc=abc; viewer = 1 ;;novalue; =skip; token=a=b\n"), FormatHeader, "comic-days.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cookie{
+		{Domain: ".comic-days.com", Path: "/", Name: "glsc", Value: "abc", Session: true},
+		{Domain: ".comic-days.com", Path: "/", Name: "viewer", Value: "1", Session: true},
+		{Domain: ".comic-days.com", Path: "/", Name: "token", Value: "a=b", Session: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	if _, err := Parse([]byte("a=1"), FormatHeader, ""); err == nil {
+		t.Error("header without a domain succeeded")
+	}
+	if _, err := Parse([]byte("cookie: ; ;"), FormatHeader, "comic-days.com"); err == nil {
+		t.Error("empty header succeeded")
+	}
+}
+
+func TestParseHAR(t *testing.T) {
+	got, err := Parse(readFixture(t, "session.har"), FormatAuto, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cookie{
+		// 图片域名上的请求 cookie 按主域名保存，之后被响应中刷新的值覆盖
+		{Domain: ".comic-days.com", Path: "/", Secure: true, HTTPOnly: true, Name: "glsc", Value: "refreshed", ExpirationDate: farFuture},
+		{Domain: ".comic-days.com", Path: "/", Name: "viewer", Value: "1", Session: true},
+		// 没有域名和路径的 Set-Cookie 只属于请求的主机和默认路径
+		{Domain: "comic-days.com", HostOnly: true, Path: "/episode", Name: "host", Value: "only", Session: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	if _, err := Parse([]byte(`{"log": [}`), FormatHAR, ""); err == nil {
+		t.Error("malformed HAR succeeded")
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	got, err := Parse(readFixture(t, "cookie-editor.json"), FormatAuto, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].SameSite != "lax" || got[0].StoreID != "0" {
+		t.Errorf("got %+v", got)
+	}
+	if _, err := Parse([]byte(`[{"name": 1}]`), FormatJSON, ""); err == nil {
+		t.Error("malformed JSON succeeded")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		"fixture:cookies.txt":        FormatNetscape,
+		"fixture:malformed.txt":      FormatNetscape,
+		"fixture:session.har":        FormatHAR,
+		"fixture:cookie-editor.json": FormatJSON,
+		"  [ ]":                      FormatJSON,
+		"# HTTP Cookie File\n":       FormatNetscape,
+		"glsc=abc; viewer=1":         FormatHeader,
+		"Cookie: glsc=abc":           FormatHeader,
+	}
+	for in, want := range tests {
+		data := []byte(in)
+		if name, ok := strings.CutPrefix(in, "fixture:"); ok {
+			data = readFixture(t, name)
+		}
+		if got := Detect(data); got != want {
+			t.Errorf("Detect(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestFilterDomains(t *testing.T) {
+	list, err := Parse(readFixture(t, "cookies.txt"), FormatNetscape, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range FilterDomains(list, []string{"Comic-Days.com", "shonenmagazine.com"}) {
+		names = append(names, c.Name)
+	}
+	if want := []string{"glsc", "viewer", "old", "trailing"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+	// 只是后缀相同的域名不算子域名
+	if got := FilterDomains([]Cookie{{Domain: ".notcomic-days.com", Name: "x"}}, []string{"comic-days.com"}); len(got) != 0 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+// cookieSite 只用于导入测试的站点
+type cookieSite struct {
+	name    string
+	domains []string
+	file    string
+}
+
+func (s *cookieSite) Name() string            { return s.name }
+func (s *cookieSite) Match(string) bool       { return false }
+func (s *cookieSite) CookieDomains() []string { return s.domains }
+func (s *cookieSite) DefaultSettings() provider.Settings {
+	return provider.Settings{CookieFile: s.file}
+}
+func (s *cookieSite) Settings() provider.Settings { return s.DefaultSettings() }
+func (s *cookieSite) Configure(provider.Settings) {}
+func (s *cookieSite) FetchEpisode(context.Context, string) (*provider.Episode, error) {
+	return nil, nil
+}
+func (s *cookieSite) FetchPage(context.Context, *provider.Episode, int) (*provider.Image, error) {
+	return nil, nil
+}
+
+func TestImportSites(t *testing.T) {
+	dir := t.TempDir()
+	cd := &cookieSite{"comicDays", []string{"comic-days.com"}, filepath.Join(dir, "cookie.cd.json")}
+	of := &cookieSite{"ourfeel", []string{"ourfeel.jp"}, filepath.Join(dir, "cookie.of.json")}
+	sites := []provider.Provider{cd, of}
+
+	got, err := ImportSites(readFixture(t, "cookies.txt"), FormatAuto, sites)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 过期的 old 不导入
+	if want := map[string]int{"comicDays": 2, "ourfeel": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("imported %v, want %v", got, want)
+	}
+	store, err := Shared(cd.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := store.Session("glsc"); err != nil || c.Value != "session-token" {
+		t.Errorf("Session(glsc) = %+v, %v", c, err)
+	}
+
+	// 再次导入时替换同名 cookie
+	if _, err := ImportSites([]byte("glsc=new"), FormatHeader, []provider.Provider{cd}); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := store.Session("glsc"); c.Value != "new" {
+		t.Errorf("glsc = %q after re-import, want new", c.Value)
+	}
+
+	if _, err := ImportSites([]byte("glsc=x"), FormatHeader, sites); err == nil {
+		t.Error("header import into several sites succeeded")
+	}
+	other := "www.example.com\tFALSE\t/\tFALSE\t0\tother\tx\n"
+	if _, err := ImportSites([]byte(other), FormatAuto, sites); err == nil {
+		t.Error("import without matching cookies succeeded")
+	}
+}
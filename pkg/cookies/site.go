@@ -0,0 +1,105 @@
+package cookies
+
+import (
+	"fmt"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// SiteFile 返回站点当前使用的 cookie 文件和 cookie 所属的域名，站点不使用 cookie 时返回错误
+func SiteFile(p provider.Provider) (string, []string, error) {
+	cs, ok := p.(provider.CookieSite)
+	if !ok {
+		return "", nil, fmt.Errorf("%s 不使用 cookie", p.Name())
+	}
+	c, ok := p.(provider.Configurable)
+	if !ok || c.Settings().CookieFile == "" {
+		return "", nil, fmt.Errorf("%s 没有设置 cookie 文件", p.Name())
+	}
+	return c.Settings().CookieFile, cs.CookieDomains(), nil
+}
+
+// ImportSites 把 data 中的 cookie 按域名分别导入各站点的 cookie 文件，返回每个站点导入的条数。
+// sites 为空时尝试所有使用 cookie 的站点；Cookie 请求头没有域名，只能导入到指定的一个站点。
+func ImportSites(data []byte, format string, sites []provider.Provider) (map[string]int, error) {
+	targets, err := siteTargets(sites)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" || format == FormatAuto {
+		format = Detect(data)
+	}
+	if format == FormatHeader && len(targets) != 1 {
+		return nil, fmt.Errorf("Cookie 请求头中没有域名，需要指定一个站点")
+	}
+
+	list, err := Parse(data, format, targets[0].domains[0])
+	if err != nil {
+		return nil, err
+	}
+	return importTargets(list, targets)
+}
+
+// ImportBrowser 从浏览器配置目录或 cookie 数据库读取 cookie，按域名导入各站点的 cookie 文件。
+// sites 为空时尝试所有使用 cookie 的站点。
+func ImportBrowser(profile string, sites []provider.Provider) (map[string]int, error) {
+	targets, err := siteTargets(sites)
+	if err != nil {
+		return nil, err
+	}
+	list, _, err := ReadBrowser(profile)
+	if err != nil {
+		return nil, err
+	}
+	return importTargets(list, targets)
+}
+
+// target 一个站点的 cookie 文件
+type target struct {
+	name    string
+	path    string
+	domains []string
+}
+
+// siteTargets 找到各站点的 cookie 文件，sites 为空时使用所有使用 cookie 的站点
+func siteTargets(sites []provider.Provider) ([]target, error) {
+	if len(sites) == 0 {
+		for _, p := range provider.List() {
+			if _, ok := p.(provider.CookieSite); ok {
+				sites = append(sites, p)
+			}
+		}
+	}
+	targets := make([]target, 0, len(sites))
+	for _, p := range sites {
+		path, domains, err := SiteFile(p)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target{p.Name(), path, domains})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("没有使用 cookie 的站点")
+	}
+	return targets, nil
+}
+
+// importTargets 把 list 中属于各站点的 cookie 导入对应的文件
+func importTargets(list []Cookie, targets []target) (map[string]int, error) {
+	result := make(map[string]int)
+	for _, t := range targets {
+		matched := FilterDomains(list, t.domains)
+		if len(matched) == 0 {
+			continue
+		}
+		n, err := importList(t.path, matched)
+		if err != nil {
+			return result, err
+		}
+		result[t.name] = n
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("没有找到属于支持站点的 cookie")
+	}
+	return result, nil
+}
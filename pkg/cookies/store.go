@@ -0,0 +1,379 @@
+// Package cookies 管理各站点共用的 cookie 文件。
+// 文件中的 cookie 按域名、路径、Secure 和过期时间加载到 cookiejar，只在适用的请求中发送；
+// 响应中的 Set-Cookie 会更新到文件中，登录状态刷新后不需要重新导出。
+package cookies
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"mg-Downloader/internal/fsutil"
+	"mg-Downloader/pkg/provider"
+)
+
+// Cookie 文件中的一条 cookie，与 Cookie-Editor 插件导出的 JSON 格式相同
+type Cookie struct {
+	Domain         string  `json:"domain"`
+	ExpirationDate float64 `json:"expirationDate,omitempty"`
+	HostOnly       bool    `json:"hostOnly"`
+	HTTPOnly       bool    `json:"httpOnly"`
+	Name           string  `json:"name"`
+	Path           string  `json:"path"`
+	SameSite       string  `json:"sameSite,omitempty"`
+	Secure         bool    `json:"secure"`
+	Session        bool    `json:"session"`
+	StoreID        string  `json:"storeId,omitempty"`
+	Value          string  `json:"value"`
+}
+
+// Expires 过期时间，会话 cookie 返回零值
+func (c Cookie) Expires() time.Time {
+	if c.Session || c.ExpirationDate <= 0 {
+		return time.Time{}
+	}
+	sec := int64(c.ExpirationDate)
+	return time.Unix(sec, int64((c.ExpirationDate-float64(sec))*1e9))
+}
+
+// Expired 是否已经过期
+func (c Cookie) Expired(now time.Time) bool {
+	exp := c.Expires()
+	return !exp.IsZero() && !exp.After(now)
+}
+
+// url 能够收到该 cookie 的地址，用于加载到 cookiejar
+func (c Cookie) url() *url.URL {
+	scheme := "http"
+	if c.Secure {
+		scheme = "https"
+	}
+	p := c.Path
+	if p == "" {
+		p = "/"
+	}
+	return &url.URL{Scheme: scheme, Host: strings.TrimPrefix(c.Domain, "."), Path: p}
+}
+
+func (c Cookie) http() *http.Cookie {
+	hc := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+		Expires:  c.Expires(),
+	}
+	if !c.HostOnly {
+		hc.Domain = c.Domain
+	}
+	return hc
+}
+
+// Store 一个 cookie 文件，实现 http.CookieJar，可以被多个 goroutine 同时使用
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	cookies []Cookie
+	modTime time.Time
+	dirty   bool
+	// expired 文件中已过期或被站点删除的 cookie，用于区分未登录和登录过期
+	expired []Cookie
+	// loadErr 文件无法解析时不写回，避免覆盖用户的文件
+	loadErr error
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[string]*Store)
+)
+
+// Shared 返回 path 对应的 Store，同一个文件在进程内只有一个 Store，
+// 同时进行的任务不会互相覆盖。文件在外部被修改后重新加载。
+// 文件不存在时返回空的 Store；文件无法解析时仍返回空的 Store 和错误。
+func Shared(path string) (*Store, error) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+	storesMu.Lock()
+	s, ok := stores[key]
+	if !ok {
+		s = &Store{path: path}
+		stores[key] = s
+	}
+	storesMu.Unlock()
+
+	return s, s.reloadIfChanged()
+}
+
+// Path 返回 cookie 文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// reloadIfChanged 第一次使用或文件被外部修改、删除时重新读取
+func (s *Store) reloadIfChanged() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		if s.jar == nil || !s.modTime.IsZero() {
+			s.reset(nil)
+			s.modTime = time.Time{}
+		}
+		s.loadErr = nil
+		return nil
+	}
+	if err == nil && s.jar != nil && info.ModTime().Equal(s.modTime) {
+		return s.loadErr
+	}
+
+	s.loadErr = s.load(info, err)
+	return s.loadErr
+}
+
+// load 读取文件并重建 cookiejar，出错时 Store 为空，调用时需持有 mu
+func (s *Store) load(info os.FileInfo, statErr error) error {
+	s.reset(nil)
+	if statErr != nil {
+		return fmt.Errorf("读取 cookie 文件失败: %w", statErr)
+	}
+	s.modTime = info.ModTime()
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("读取 cookie 文件失败: %w", err)
+	}
+	list, err := decodeFile(data)
+	if err != nil {
+		return fmt.Errorf("解析 cookie 文件 %s 失败: %w", s.path, err)
+	}
+	s.reset(list)
+	return nil
+}
+
+// decodeFile 解析 cookie 文件，空文件视为没有 cookie
+func decodeFile(data []byte) ([]Cookie, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+	var list []Cookie
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// reset 用 list 重建 cookiejar，调用时需持有 mu
+func (s *Store) reset(list []Cookie) {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	now := time.Now()
+	s.cookies = s.cookies[:0]
+	s.expired = nil
+	for _, c := range list {
+		if c.Name == "" || c.Domain == "" {
+			continue
+		}
+		if c.Expired(now) {
+			s.expired = append(s.expired, c)
+			continue
+		}
+		s.cookies = append(s.cookies, c)
+		jar.SetCookies(c.url(), []*http.Cookie{c.http()})
+	}
+	s.jar = jar
+	s.dirty = false
+}
+
+// Cookies 实现 http.CookieJar，返回发往 u 时应携带的 cookie
+func (s *Store) Cookies(u *url.URL) []*http.Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jar.Cookies(u)
+}
+
+// SetCookies 实现 http.CookieJar，记录响应中的 Set-Cookie
+func (s *Store) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	for _, hc := range cookies {
+		c := fromHTTP(u, hc, now)
+		i := s.index(c)
+		switch {
+		case c.Expired(now) && i >= 0:
+			s.expired = append(s.expired, s.cookies[i])
+			s.cookies = append(s.cookies[:i], s.cookies[i+1:]...)
+		case c.Expired(now):
+			continue
+		case i >= 0:
+			// 保留浏览器导出的附加字段
+			c.SameSite, c.StoreID = s.cookies[i].SameSite, s.cookies[i].StoreID
+			s.cookies[i] = c
+		default:
+			s.cookies = append(s.cookies, c)
+		}
+		s.dirty = true
+	}
+}
+
+// index 查找名称、域名和路径都相同的 cookie
+func (s *Store) index(c Cookie) int {
+	for i, old := range s.cookies {
+		if old.Name == c.Name && strings.EqualFold(old.Domain, c.Domain) && old.Path == c.Path {
+			return i
+		}
+	}
+	return -1
+}
+
+// fromHTTP 按 RFC 6265 的规则补全 Set-Cookie 中省略的域名、路径和过期时间
+func fromHTTP(u *url.URL, hc *http.Cookie, now time.Time) Cookie {
+	c := Cookie{
+		Name:     hc.Name,
+		Value:    hc.Value,
+		Path:     hc.Path,
+		Secure:   hc.Secure,
+		HTTPOnly: hc.HttpOnly,
+		Session:  true,
+	}
+	if hc.Domain != "" {
+		c.Domain = "." + strings.TrimPrefix(strings.ToLower(hc.Domain), ".")
+	} else {
+		c.Domain = strings.ToLower(u.Hostname())
+		c.HostOnly = true
+	}
+	if c.Path == "" || !strings.HasPrefix(c.Path, "/") {
+		c.Path = defaultPath(u.Path)
+	}
+
+	var exp time.Time
+	switch {
+	case hc.MaxAge < 0:
+		exp = now.Add(-time.Second)
+	case hc.MaxAge > 0:
+		exp = now.Add(time.Duration(hc.MaxAge) * time.Second)
+	case !hc.Expires.IsZero():
+		exp = hc.Expires
+	}
+	if !exp.IsZero() {
+		c.Session = false
+		c.ExpirationDate = float64(exp.Unix())
+	}
+	return c
+}
+
+// defaultPath RFC 6265 5.1.4 中的默认路径
+func defaultPath(p string) string {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+	dir := path.Dir(p)
+	if dir == "." {
+		return "/"
+	}
+	return dir
+}
+
+// All 返回当前所有未过期的 cookie
+func (s *Store) All() []Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	list := make([]Cookie, 0, len(s.cookies))
+	for _, c := range s.cookies {
+		if !c.Expired(now) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// Session 返回登录用的 cookie，names 为空时任意一条 cookie 都可以。
+// 没有未过期的 cookie 时，曾经有过但已过期或被站点删除的返回 provider.ErrCookieExpired，
+// 否则返回 provider.ErrNotLoggedIn。
+func (s *Store) Session(names ...string) (Cookie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	match := func(c Cookie) bool {
+		if len(names) == 0 {
+			return true
+		}
+		for _, name := range names {
+			if c.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	now := time.Now()
+	for _, c := range s.cookies {
+		if match(c) && !c.Expired(now) {
+			return c, nil
+		}
+	}
+	for _, c := range s.cookies {
+		if match(c) {
+			return c, fmt.Errorf("%w: %s 已于 %s 过期", provider.ErrCookieExpired, c.Name, c.Expires().Format("2006-01-02 15:04"))
+		}
+	}
+	for _, c := range s.expired {
+		if match(c) {
+			return c, fmt.Errorf("%w: %s 已失效，请重新导入 %s", provider.ErrCookieExpired, c.Name, s.path)
+		}
+	}
+	return Cookie{}, fmt.Errorf("%w: %s 中没有登录 cookie", provider.ErrNotLoggedIn, s.path)
+}
+
+// Save 有变化时写回 cookie 文件，先写临时文件再改名
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	if s.loadErr != nil {
+		return fmt.Errorf("cookie 文件无法读取，不写回更新: %w", s.loadErr)
+	}
+
+	now := time.Now()
+	list := make([]Cookie, 0, len(s.cookies))
+	for _, c := range s.cookies {
+		if !c.Expired(now) {
+			list = append(list, c)
+		}
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 cookie 失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建 cookie 目录失败: %w", err)
+	}
+	// cookie 中有登录凭据，只允许自己读取
+	if err := fsutil.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("保存 cookie 失败: %w", err)
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	s.dirty = false
+	return nil
+}
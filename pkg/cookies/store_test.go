@@ -0,0 +1,258 @@
+package cookies
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// openFixture 把 testdata 中的 cookie 文件复制到临时目录后打开
+func openFixture(t *testing.T, name string) *Store {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cookie.json")
+	if err := os.WriteFile(path, readFixture(t, name), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Shared(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func names(list []*http.Cookie) []string {
+	var out []string
+	for _, c := range list {
+		out = append(out, c.Name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func mustURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func TestStoreLoad(t *testing.T) {
+	store := openFixture(t, "cookie-editor.json")
+
+	if all := store.All(); len(all) != 2 {
+		t.Errorf("All() = %+v, want glsc and viewer", all)
+	}
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"https://comic-days.com/episode/1", []string{"glsc", "viewer"}},
+		{"https://cdn-img.comic-days.com/page.jpg", []string{"glsc"}},
+		// glsc 只在 https 下发送，viewer 只属于 /episode 路径和主域名本身
+		{"http://comic-days.com/episode/1", []string{"viewer"}},
+		{"https://comic-days.com/", []string{"glsc"}},
+		{"https://ourfeel.jp/episode/1", nil},
+	}
+	for _, tt := range tests {
+		if got := names(store.Cookies(mustURL(tt.url))); !equal(got, tt.want) {
+			t.Errorf("Cookies(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestStoreSession(t *testing.T) {
+	store := openFixture(t, "cookie-editor.json")
+
+	if c, err := store.Session("glsc"); err != nil || c.Value != "valid" {
+		t.Errorf("Session(glsc) = %+v, %v", c, err)
+	}
+	if _, err := store.Session("stale"); !errors.Is(err, provider.ErrCookieExpired) {
+		t.Errorf("Session(stale) = %v, want ErrCookieExpired", err)
+	}
+	if _, err := store.Session("missing"); !errors.Is(err, provider.ErrNotLoggedIn) {
+		t.Errorf("Session(missing) = %v, want ErrNotLoggedIn", err)
+	}
+	if _, err := store.Session(); err != nil {
+		t.Errorf("Session() = %v", err)
+	}
+}
+
+func TestStoreSetCookiesPersisted(t *testing.T) {
+	store := openFixture(t, "cookie-editor.json")
+
+	u := mustURL("https://comic-days.com/episode/1")
+	store.SetCookies(u, []*http.Cookie{
+		{Name: "glsc", Value: "refreshed", Domain: "comic-days.com", Path: "/", Secure: true, HttpOnly: true, MaxAge: 3600},
+		// 站点删除 cookie
+		{Name: "viewer", Path: "/episode", MaxAge: -1},
+		{Name: "added", Value: "1"},
+	})
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("cookie file mode = %v, want 0600", mode)
+	}
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := decodeFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := make(map[string]Cookie)
+	for _, c := range list {
+		saved[c.Name] = c
+	}
+
+	if len(saved) != 2 {
+		t.Errorf("saved %+v, want glsc and added", list)
+	}
+	glsc := saved["glsc"]
+	if glsc.Value != "refreshed" || glsc.Session || glsc.Expires().Before(time.Now().Add(time.Hour-time.Minute)) {
+		t.Errorf("glsc = %+v", glsc)
+	}
+	// 浏览器导出的附加字段保留下来
+	if glsc.SameSite != "lax" || glsc.StoreID != "0" {
+		t.Errorf("glsc lost SameSite/StoreID: %+v", glsc)
+	}
+	added := saved["added"]
+	if added.Domain != "comic-days.com" || !added.HostOnly || added.Path != "/episode" || !added.Session {
+		t.Errorf("added = %+v", added)
+	}
+
+	if _, err := store.Session("viewer"); !errors.Is(err, provider.ErrCookieExpired) {
+		t.Errorf("Session(viewer) = %v, want ErrCookieExpired after deletion", err)
+	}
+}
+
+func TestStoreSaveUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies", "cookie.json")
+	store, err := Shared(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Save without changes created the file: %v", err)
+	}
+	// 导入后创建目录和文件
+	if n := store.Import([]Cookie{{Domain: ".comic-days.com", Name: "glsc", Value: "x"}}); n != 1 {
+		t.Fatalf("Import = %d, want 1", n)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStoreReloadsExternalChanges(t *testing.T) {
+	store := openFixture(t, "cookie-editor.json")
+	path := store.Path()
+
+	data := `[{"domain": ".ourfeel.jp", "name": "glsc", "path": "/", "session": true, "value": "other"}]`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// 避免文件系统的时间精度不足以区分两次修改
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := Shared(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != store {
+		t.Fatal("Shared returned a different Store for the same file")
+	}
+	if c, err := store.Session("glsc"); err != nil || c.Value != "other" {
+		t.Errorf("Session(glsc) = %+v, %v after external change", c, err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Shared(path); err != nil {
+		t.Fatal(err)
+	}
+	if all := store.All(); len(all) != 0 {
+		t.Errorf("All() = %+v after the file was deleted", all)
+	}
+}
+
+func TestStoreInvalidFileNotOverwritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookie.json")
+	const broken = `[{"name": "glsc",`
+	if err := os.WriteFile(path, []byte(broken), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Shared(path)
+	if err == nil {
+		t.Fatal("Shared of a malformed file succeeded")
+	}
+
+	store.SetCookies(mustURL("https://comic-days.com/"), []*http.Cookie{{Name: "glsc", Value: "x"}})
+	if err := store.Save(); err == nil {
+		t.Error("Save over a malformed file succeeded")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != broken {
+		t.Errorf("malformed file was overwritten with %q", data)
+	}
+}
+
+func TestCookieExpires(t *testing.T) {
+	now := time.Unix(2000000000, 0)
+	tests := []struct {
+		c       Cookie
+		expired bool
+	}{
+		{Cookie{Session: true}, false},
+		{Cookie{ExpirationDate: 0}, false},
+		{Cookie{ExpirationDate: 1999999999.5}, true},
+		{Cookie{ExpirationDate: 2000000000}, true},
+		{Cookie{ExpirationDate: 2000000000.25}, false},
+		// 会话 cookie 忽略过期时间
+		{Cookie{Session: true, ExpirationDate: 1}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Expired(now); got != tt.expired {
+			t.Errorf("%+v.Expired = %v, want %v", tt.c, got, tt.expired)
+		}
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+#!/usr/bin/env python3
+"""生成浏览器 cookie 数据库的测试数据，在 testdata 目录中运行：python3 gen.py
+
+加密的值用 openssl 命令生成，与 Linux 上没有系统钥匙串的 Chromium 相同：
+AES-128-CBC，密钥由密码 "peanuts" 和盐 "saltysalt" 经一次 PBKDF2-HMAC-SHA1 得到，IV 为 16 个空格。"""
+import hashlib
+import os
+import sqlite3
+import subprocess
+
+# 2100-01-01
+FAR_FUTURE = 4102444800
+# Chromium 的时间从 1601-01-01 开始，以微秒计
+CHROMIUM_EPOCH = 11644473600
+
+
+def chromium_time(unix):
+    return (unix + CHROMIUM_EPOCH) * 1000000
+
+
+def v10(plain):
+    key = hashlib.pbkdf2_hmac("sha1", b"peanuts", b"saltysalt", 1, 16)
+    enc = subprocess.run(
+        ["openssl", "enc", "-aes-128-cbc", "-K", key.hex(), "-iv", (b" " * 16).hex()],
+        input=plain, stdout=subprocess.PIPE, check=True).stdout
+    return b"v10" + enc
+
+
+def create(path, script, rows=()):
+    os.makedirs(os.path.dirname(path), exist_ok=True)
+    for suffix in ("", "-wal", "-shm", "-journal"):
+        if os.path.exists(path + suffix):
+            os.remove(path + suffix)
+    db = sqlite3.connect(path, isolation_level=None)
+    db.executescript(script)
+    for sql, values in rows:
+        db.execute(sql, values)
+    db.close()
+
+
+def firefox():
+    """Firefox 的 moz_cookies：新版本以毫秒保存过期时间，会话 cookie 的过期时间为 0"""
+    insert = ("INSERT INTO moz_cookies (name, value, host, path, expiry, lastAccessed, creationTime, isSecure, isHttpOnly) "
+              "VALUES (?, ?, ?, ?, ?, 0, 0, ?, ?)")
+    create("firefox/cookies.sqlite", """
+        CREATE TABLE moz_cookies (id INTEGER PRIMARY KEY, originAttributes TEXT NOT NULL DEFAULT '', name TEXT, value TEXT,
+            host TEXT, path TEXT, expiry INTEGER, lastAccessed INTEGER, creationTime INTEGER, isSecure INTEGER,
+            isHttpOnly INTEGER, inBrowserElement INTEGER DEFAULT 0, sameSite INTEGER DEFAULT 0,
+            rawSameSite INTEGER DEFAULT 0, schemeMap INTEGER DEFAULT 0,
+            CONSTRAINT moz_uniqueid UNIQUE (name, host, path, originAttributes));
+    """, [
+        (insert, ("glsc", "firefox-token", ".comic-days.com", "/", FAR_FUTURE, 1, 1)),
+        (insert, ("viewer", "1", "comic-days.com", "/episode", FAR_FUTURE * 1000, 0, 0)),
+        (insert, ("glsc", "session", ".ourfeel.jp", "/", 0, 1, 1)),
+        (insert, ("old", "expired", ".comic-days.com", "/", 1000000000, 0, 0)),
+        (insert, ("other", "x", ".example.com", "/", FAR_FUTURE, 0, 0)),
+    ])
+
+
+CHROMIUM_INSERT = ("INSERT INTO cookies (creation_utc, host_key, top_frame_site_key, name, value, encrypted_value, path, "
+                   "expires_utc, is_secure, is_httponly, last_access_utc, has_expires, is_persistent, priority, samesite, "
+                   "source_scheme, source_port, last_update_utc, source_type, has_cross_site_ancestor) "
+                   "VALUES (0, ?, '', ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, 1, -1, 2, 443, 0, 0, 0)")
+
+CHROMIUM_SCHEMA = """
+    CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR);
+    INSERT INTO meta VALUES ('version', '%d'), ('last_compatible_version', '24');
+    CREATE TABLE cookies (creation_utc INTEGER NOT NULL, host_key TEXT NOT NULL, top_frame_site_key TEXT NOT NULL,
+        name TEXT NOT NULL, value TEXT NOT NULL, encrypted_value BLOB NOT NULL, path TEXT NOT NULL,
+        expires_utc INTEGER NOT NULL, is_secure INTEGER NOT NULL, is_httponly INTEGER NOT NULL,
+        last_access_utc INTEGER NOT NULL, has_expires INTEGER NOT NULL, is_persistent INTEGER NOT NULL,
+        priority INTEGER NOT NULL, samesite INTEGER NOT NULL, source_scheme INTEGER NOT NULL,
+        source_port INTEGER NOT NULL, last_update_utc INTEGER NOT NULL, source_type INTEGER NOT NULL,
+        has_cross_site_ancestor INTEGER NOT NULL);
+"""
+
+
+def chromium_row(host, name, value, enc, path, expires, secure, http_only):
+    persistent = 1 if expires else 0
+    return (CHROMIUM_INSERT, (host, name, value, enc, path, chromium_time(expires) if expires else 0,
+                              secure, http_only, persistent, persistent))
+
+
+def chromium():
+    """数据库版本 24 的 Chromium 配置目录：加密值前带有域名的 SHA-256，
+    v11 使用系统钥匙串加密，无法读取时跳过"""
+    digest = hashlib.sha256(b".comic-days.com").digest()
+    create("chromium/Network/Cookies", CHROMIUM_SCHEMA % 24, [
+        chromium_row(".comic-days.com", "glsc", "", v10(digest + b"chromium-token"), "/", FAR_FUTURE, 1, 1),
+        chromium_row("comic-days.com", "viewer", "1", b"", "/episode", 0, 0, 0),
+        chromium_row(".ourfeel.jp", "glsc", "", b"v11" + bytes(32), "/", FAR_FUTURE, 1, 1),
+    ])
+
+
+def chromium_legacy():
+    """旧版本的 Chromium：列名为 secure、httponly，加密值前没有域名的哈希"""
+    insert = ("INSERT INTO cookies (creation_utc, host_key, name, value, path, expires_utc, secure, httponly, "
+              "last_access_utc, has_expires, persistent, priority, encrypted_value) "
+              "VALUES (0, ?, ?, ?, ?, ?, ?, ?, 0, 1, 1, 1, ?)")
+    create("chromium-legacy/Cookies", """
+        CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR);
+        INSERT INTO meta VALUES ('version', '10');
+        CREATE TABLE cookies (creation_utc INTEGER NOT NULL UNIQUE PRIMARY KEY, host_key TEXT NOT NULL,
+            name TEXT NOT NULL, value TEXT NOT NULL, path TEXT NOT NULL, expires_utc INTEGER NOT NULL,
+            secure INTEGER NOT NULL, httponly INTEGER NOT NULL, last_access_utc INTEGER NOT NULL,
+            has_expires INTEGER NOT NULL DEFAULT 1, persistent INTEGER NOT NULL DEFAULT 1,
+            priority INTEGER NOT NULL DEFAULT 1, encrypted_value BLOB DEFAULT '');
+    """, [
+        (insert, (".comic-days.com", "glsc", "", "/", chromium_time(FAR_FUTURE), 1, 1, v10(b"legacy-token"))),
+    ])
+
+
+def chromium_keyring():
+    """所有值都使用系统钥匙串加密"""
+    create("chromium-keyring/Cookies", CHROMIUM_SCHEMA % 24, [
+        chromium_row(".comic-days.com", "glsc", "", b"v11" + bytes(32), "/", FAR_FUTURE, 1, 1),
+    ])
+
+
+firefox()
+chromium()
+chromium_legacy()
+chromium_keyring()
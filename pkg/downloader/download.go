@@ -0,0 +1,298 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mg-Downloader/internal/fsutil"
+	"mg-Downloader/pkg/exporter"
+	"mg-Downloader/pkg/pathtmpl"
+	"mg-Downloader/pkg/provider"
+	"mg-Downloader/pkg/selection"
+)
+
+// Options 单话下载的参数
+type Options struct {
+	// Workers 并行下载的页数，小于 1 时按 1 处理
+	Workers int
+	// Stop 关闭后不再派发新页面，Download 返回暂停错误
+	Stop <-chan struct{}
+	// Progress 每保存一页调用一次，done 为已完成的页数
+	Progress func(done, total int)
+	// Format 输出格式，为空或 images 时散图保存在输出目录中，否则下载完成后打包
+	Format string
+	// Pages 只下载这些页（页码从 1 开始），为空时下载整话；打包时也只包含这些页
+	Pages []selection.Range
+	// Export 打包选项，只在 Format 为打包格式时使用
+	Export exporter.Options
+	// Template 输出路径模板，为空时使用 pathtmpl.Default。
+	// 打包时模板中的文件夹部分作为打包文件名，没有文件夹时按作品名和话数命名
+	Template *pathtmpl.Template
+}
+
+// Download 将章节下载到 outDir，清单中已完成且校验通过的页面会跳过。
+// 需要打包时页面先下载到 outDir 下的临时目录，打包成功后删除。
+// GUI 的下载队列和命令行共用这一实现。
+func Download(ctx context.Context, p provider.Provider, ep *provider.Episode, outDir string, opts Options) error {
+	if f, ok := p.(provider.EpisodeFinisher); ok {
+		defer f.FinishEpisode(ep)
+	}
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl, _ = pathtmpl.Parse(pathtmpl.Default)
+	}
+	vars := templateVars(p, ep)
+
+	if opts.Format == "" || opts.Format == exporter.FormatImages {
+		return downloadPages(ctx, p, ep, filepath.Join(outDir, tmpl.Dir(vars)), tmpl, opts)
+	}
+
+	exp, err := exporter.Get(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	// 临时目录中始终使用默认文件名
+	stageDir := filepath.Join(outDir, "."+safeName(ep.ID)+".part")
+	if err := downloadPages(ctx, p, ep, stageDir, nil, opts); err != nil {
+		return err
+	}
+
+	dst := filepath.Join(outDir, archiveName(ep)+"."+exp.Ext())
+	if tmpl.HasDir() {
+		dst = filepath.Join(outDir, tmpl.Dir(vars)+"."+exp.Ext())
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %w", err)
+		}
+	}
+	if err := exportPages(stageDir, opts.Format, dst, opts.Pages, opts.Export); err != nil {
+		return err
+	}
+	return os.RemoveAll(stageDir)
+}
+
+// Export 将 dir 中已下载完成的一话按清单顺序打包为 format 格式的 dst 文件
+func Export(dir, format, dst string, opts exporter.Options) error {
+	return exportPages(dir, format, dst, nil, opts)
+}
+
+// exportPages 打包 pages 选中的页，pages 为空时打包整话
+func exportPages(dir, format, dst string, pages []selection.Range, opts exporter.Options) error {
+	exp, err := exporter.Get(format)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return fmt.Errorf("目录中没有下载清单，无法确定页面顺序")
+	}
+
+	var files []string
+	for _, page := range manifest.Pages {
+		if !pageSelected(pages, page.Index) {
+			continue
+		}
+		if page.Status != PageDone {
+			return fmt.Errorf("第 %d 页尚未下载完成", page.Index+1)
+		}
+		files = append(files, filepath.Join(dir, page.File))
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("没有可打包的页面")
+	}
+
+	meta := manifest.Meta
+	if meta.Title == "" {
+		meta.Title = manifest.Title
+	}
+	meta.PageCount = len(files)
+
+	log.Printf("[Download] 📦 打包为 %s: %s", format, dst)
+	if err := exp.Export(dst, meta, files, opts); err != nil {
+		return fmt.Errorf("打包失败: %w", err)
+	}
+	return nil
+}
+
+// downloadPages 将页面散图下载到 outDir，文件名由 tmpl 生成，为 nil 时使用默认文件名
+func downloadPages(ctx context.Context, p provider.Provider, ep *provider.Episode, outDir string, tmpl *pathtmpl.Template, opts Options) error {
+	if tmpl == nil {
+		tmpl, _ = pathtmpl.Parse(pathtmpl.Default)
+	}
+	vars := templateVars(p, ep)
+
+	// 创建输出目录
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	// 根据输出目录中的清单跳过已完成且校验通过的页面
+	manifest := openManifest(outDir, p.Name(), ep)
+	if err := manifest.save(outDir); err != nil {
+		return err
+	}
+	// 只处理选中的页，进度也按选中的页数计算
+	var indexes []int
+	for _, index := range manifest.Missing() {
+		if pageSelected(opts.Pages, index) {
+			indexes = append(indexes, index)
+		}
+	}
+	totalPages, donePages := 0, 0
+	for _, page := range manifest.Pages {
+		if pageSelected(opts.Pages, page.Index) {
+			totalPages++
+			if page.Status == PageDone {
+				donePages++
+			}
+		}
+	}
+	if totalPages == 0 {
+		return fmt.Errorf("所选页码超出范围，本话共 %d 页", len(ep.Pages))
+	}
+	log.Printf("[Download] 下载%s: %s (%d页，剩余%d页)", p.Name(), ep.Title, totalPages, len(indexes))
+
+	progress := func() {
+		if opts.Progress != nil {
+			opts.Progress(donePages, totalPages)
+		}
+	}
+	progress()
+
+	// 页面并行下载，按顺序写入文件和发送进度
+	failed := 0
+	err := fetchPages(ctx, p, ep, indexes, opts.Workers, opts.Stop, func(res pageResult) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		pageNum := res.index + 1
+		if res.err != nil {
+			log.Printf("[Download] ❌ 第 %d 页下载失败: %v", pageNum, res.err)
+			manifest.markFailed(res.index, res.err)
+			failed++
+			return manifest.save(outDir)
+		}
+
+		// 保存图片文件
+		vars.Page, vars.Ext = pageNum, res.img.Ext
+		filename := tmpl.File(vars)
+		if err := fsutil.WriteFile(filepath.Join(outDir, filename), res.img.Data, 0644); err != nil {
+			log.Printf("[Download] ❌ 第 %d 页保存失败: %v", pageNum, err)
+			manifest.markFailed(res.index, err)
+			failed++
+			return manifest.save(outDir)
+		}
+		log.Printf("[Download] ✓ 第 %d 页下载完成: %s", pageNum, filename)
+
+		// 每页都更新清单，意外退出后也能从断点继续
+		manifest.markDone(res.index, filename, res.img.Data)
+		if err := manifest.save(outDir); err != nil {
+			return err
+		}
+		donePages++
+		progress()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 页下载失败，可重新下载以重试", failed)
+	}
+	return nil
+}
+
+// pageSelected 判断第 index 页（从 0 开始）是否被选中，pages 为空表示全部
+func pageSelected(pages []selection.Range, index int) bool {
+	if len(pages) == 0 {
+		return true
+	}
+	for _, r := range pages {
+		if r.Contains(index + 1) {
+			return true
+		}
+	}
+	return false
+}
+
+// templateVars 路径模板中可以使用的章节信息
+func templateVars(p provider.Provider, ep *provider.Episode) pathtmpl.Vars {
+	v := pathtmpl.Vars{
+		Site:          p.Name(),
+		Series:        ep.Series,
+		Title:         ep.Title,
+		EpisodeTitle:  ep.EpisodeTitle,
+		EpisodeID:     ep.ID,
+		EpisodeNumber: ep.Number,
+		Author:        ep.Author,
+	}
+	if v.Series == "" {
+		v.Series = ep.Title
+	}
+	if v.EpisodeTitle == "" {
+		v.EpisodeTitle = ep.Title
+	}
+	return v
+}
+
+// archiveName 打包文件的文件名，优先使用作品名和话数
+func archiveName(ep *provider.Episode) string {
+	name := ep.Title
+	if ep.Series != "" && ep.EpisodeTitle != "" {
+		name = ep.Series + " " + ep.EpisodeTitle
+	} else if ep.Series != "" && ep.Number > 0 {
+		name = ep.Series + " " + strconv.Itoa(ep.Number)
+	}
+	return safeName(name)
+}
+
+// EpisodeDirName 批量下载时每一话散图所在的文件夹名，形如 "003 第3話"，
+// 同一话无论从 GUI 还是命令行下载都得到相同的名字
+func EpisodeDirName(e provider.SeriesEpisode) string {
+	title := strings.TrimSpace(e.Title)
+	if title == "" {
+		title = e.ID
+	}
+	if e.Number > 0 {
+		return safeName(fmt.Sprintf("%03d %s", e.Number, title))
+	}
+	return safeName(title)
+}
+
+// BatchOutDir 批量下载时一话的输出目录：模板没有指定文件夹的散图放进 EpisodeDirName 文件夹，
+// 其余情况由模板或打包文件名区分各话，直接使用 outDir
+func BatchOutDir(outDir string, e provider.SeriesEpisode, format string, tmpl *pathtmpl.Template) string {
+	if (format == "" || format == exporter.FormatImages) && (tmpl == nil || !tmpl.HasDir()) {
+		return filepath.Join(outDir, EpisodeDirName(e))
+	}
+	return outDir
+}
+
+// EpisodeOutDir 下载到书库等公用目录时单话的输出目录，文件夹名与批量下载相同
+func EpisodeOutDir(outDir string, ep *provider.Episode, format string, tmpl *pathtmpl.Template) string {
+	title := ep.EpisodeTitle
+	if title == "" {
+		title = ep.Title
+	}
+	e := provider.SeriesEpisode{ID: ep.ID, URL: ep.URL, Number: ep.Number, Title: title}
+	return BatchOutDir(outDir, e, format, tmpl)
+}
+
+// safeName 去掉文件名中 Windows 和 Linux 不允许的字符
+func safeName(name string) string {
+	name = pathtmpl.Sanitize(name)
+	if name == "" {
+		return "episode"
+	}
+	return name
+}
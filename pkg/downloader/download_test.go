@@ -0,0 +1,335 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// fakeProvider 不访问网络的站点，每页的内容是 "page <index>"
+type fakeProvider struct {
+	name string
+	// delay 第 index 页下载需要的时间，为 nil 时立即完成
+	delay func(index int) time.Duration
+	// gate 不为 nil 时，blocked 为 true 的页等到 gate 关闭或 ctx 结束才完成
+	gate    chan struct{}
+	blocked func(index int) bool
+	// fail 这些页返回错误
+	fail map[int]bool
+
+	mu        sync.Mutex
+	started   []int
+	completed []int
+	finished  int
+}
+
+func (f *fakeProvider) Name() string      { return f.name }
+func (f *fakeProvider) Match(string) bool { return false }
+
+func (f *fakeProvider) FetchEpisode(ctx context.Context, url string) (*provider.Episode, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (f *fakeProvider) FetchPage(ctx context.Context, ep *provider.Episode, index int) (*provider.Image, error) {
+	f.mu.Lock()
+	f.started = append(f.started, index)
+	f.mu.Unlock()
+
+	if f.delay != nil {
+		select {
+		case <-time.After(f.delay(index)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if f.gate != nil && (f.blocked == nil || f.blocked(index)) {
+		select {
+		case <-f.gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	f.mu.Lock()
+	f.completed = append(f.completed, index)
+	f.mu.Unlock()
+	if f.fail[index] {
+		return nil, fmt.Errorf("page %d failed", index)
+	}
+	return &provider.Image{Data: pageData(index), Ext: "png"}, nil
+}
+
+func (f *fakeProvider) FinishEpisode(ep *provider.Episode) {
+	f.mu.Lock()
+	f.finished++
+	f.mu.Unlock()
+}
+
+// fetched 返回开始下载过的页，按页码排序
+func (f *fakeProvider) fetched() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := append([]int(nil), f.started...)
+	sort.Ints(list)
+	return list
+}
+
+func (f *fakeProvider) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started, f.completed = nil, nil
+}
+
+func pageData(index int) []byte {
+	return []byte(fmt.Sprintf("page %d", index))
+}
+
+func testEpisode(id string, pages int) *provider.Episode {
+	ep := &provider.Episode{ID: id, URL: "https://example.com/episode/" + id, Title: "Episode " + id}
+	for i := 0; i < pages; i++ {
+		ep.Pages = append(ep.Pages, provider.Page{Index: i, Src: fmt.Sprintf("https://example.com/%s/%d", id, i)})
+	}
+	return ep
+}
+
+// checkPages 确认输出目录中每页的内容正确，清单中全部完成
+func checkPages(t *testing.T, dir string, ep *provider.Episode) {
+	t.Helper()
+	for i := range ep.Pages {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%03d.png", i+1)))
+		if err != nil {
+			t.Errorf("page %d: %v", i, err)
+			continue
+		}
+		if string(data) != string(pageData(i)) {
+			t.Errorf("page %d = %q", i, data)
+		}
+	}
+	m, err := LoadEpisodeManifest(dir, ep.ID)
+	if err != nil || m == nil {
+		t.Fatalf("LoadEpisodeManifest = %v, %v", m, err)
+	}
+	if missing := m.Missing(); len(missing) != 0 {
+		t.Errorf("manifest still misses pages %v", missing)
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	dir := t.TempDir()
+	ep := testEpisode("1", 5)
+	p := &fakeProvider{name: "fake", fail: map[int]bool{2: true}}
+
+	err := Download(context.Background(), p, ep, dir, Options{Workers: 2})
+	if err == nil || !strings.Contains(err.Error(), "1 页下载失败") {
+		t.Fatalf("err = %v, want one failed page", err)
+	}
+	m, err := LoadEpisodeManifest(dir, ep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Missing(); !reflect.DeepEqual(got, []int{2}) {
+		t.Fatalf("Missing() = %v, want [2]", got)
+	}
+	if m.Pages[2].Status != PageFailed || m.Pages[2].Error == "" {
+		t.Errorf("page 2 = %+v", m.Pages[2])
+	}
+
+	// 第 2 页（从 1 开始）的文件被改动，第 4 页的文件被删除，都要重新下载
+	if err := os.WriteFile(filepath.Join(dir, "002.png"), []byte("corrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "004.png")); err != nil {
+		t.Fatal(err)
+	}
+
+	p.fail = nil
+	p.reset()
+	var progress [][2]int
+	err = Download(context.Background(), p, ep, dir, Options{Workers: 2, Progress: func(done, total int) {
+		progress = append(progress, [2]int{done, total})
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.fetched(), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second run fetched %v, want %v", got, want)
+	}
+	if want := [][2]int{{2, 5}, {3, 5}, {4, 5}, {5, 5}}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+	checkPages(t, dir, ep)
+	if p.finished != 2 {
+		t.Errorf("FinishEpisode called %d times, want 2", p.finished)
+	}
+
+	// 全部完成后不再下载
+	p.reset()
+	if err := Download(context.Background(), p, ep, dir, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.fetched(); len(got) != 0 {
+		t.Errorf("third run fetched %v", got)
+	}
+}
+
+func TestDownloadRestartsWhenPageCountChanges(t *testing.T) {
+	dir := t.TempDir()
+	p := &fakeProvider{name: "fake"}
+	if err := Download(context.Background(), p, testEpisode("1", 3), dir, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	p.reset()
+	ep := testEpisode("1", 4)
+	if err := Download(context.Background(), p, ep, dir, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.fetched(), []int{0, 1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fetched %v, want %v", got, want)
+	}
+	checkPages(t, dir, ep)
+}
+
+func TestFetchPagesOrder(t *testing.T) {
+	const pages = 12
+	// 越靠后的页完成得越早
+	p := &fakeProvider{name: "fake", delay: func(index int) time.Duration {
+		return time.Duration(pages-index) * 3 * time.Millisecond
+	}}
+	ep := testEpisode("1", pages)
+	indexes := []int{0, 1, 2, 3, 5, 6, 7, 8, 9, 10, 11}
+
+	var handled []int
+	err := fetchPages(context.Background(), p, ep, indexes, 4, nil, func(res pageResult) error {
+		if res.err != nil {
+			return res.err
+		}
+		if string(res.img.Data) != string(pageData(res.index)) {
+			t.Errorf("page %d has data %q", res.index, res.img.Data)
+		}
+		handled = append(handled, res.index)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(handled, indexes) {
+		t.Errorf("handled %v, want %v", handled, indexes)
+	}
+	if sort.IntsAreSorted(p.completed) {
+		t.Errorf("pages completed in order %v, the test did not exercise reordering", p.completed)
+	}
+}
+
+func TestFetchPagesWindow(t *testing.T) {
+	const workers = 2
+	// 第一页卡住时，其他页最多领先 2*workers 张
+	p := &fakeProvider{name: "fake", gate: make(chan struct{}), blocked: func(index int) bool { return index == 0 }}
+	ep := testEpisode("1", 20)
+	indexes := make([]int, len(ep.Pages))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	done := make(chan error, 1)
+	var handled []int
+	go func() {
+		done <- fetchPages(context.Background(), p, ep, indexes, workers, nil, func(res pageResult) error {
+			handled = append(handled, res.index)
+			return nil
+		})
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	p.mu.Lock()
+	started := len(p.started)
+	p.mu.Unlock()
+	if started > 2*workers {
+		t.Errorf("%d pages started while the first one was blocked, want at most %d", started, 2*workers)
+	}
+
+	close(p.gate)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(handled, indexes) {
+		t.Errorf("handled %v", handled)
+	}
+}
+
+func TestDownloadStop(t *testing.T) {
+	dir := t.TempDir()
+	ep := testEpisode("1", 10)
+	stop := make(chan struct{})
+	p := &fakeProvider{name: "fake"}
+
+	err := Download(context.Background(), p, ep, dir, Options{Workers: 1, Stop: stop, Progress: func(done, total int) {
+		if done == 3 {
+			close(stop)
+		}
+	}})
+	if !errors.Is(err, errPaused) {
+		t.Fatalf("err = %v, want errPaused", err)
+	}
+	m, err := LoadEpisodeManifest(dir, ep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 暂停时已派发的页面照常保存
+	missing := m.Missing()
+	if len(missing) == 0 || len(missing) > 7 {
+		t.Fatalf("Missing() = %v after pausing at page 3", missing)
+	}
+
+	p.reset()
+	if err := Download(context.Background(), p, ep, dir, Options{Workers: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.fetched(); !reflect.DeepEqual(got, missing) {
+		t.Errorf("resumed download fetched %v, want %v", got, missing)
+	}
+	checkPages(t, dir, ep)
+}
+
+func TestDownloadCancel(t *testing.T) {
+	dir := t.TempDir()
+	ep := testEpisode("1", 6)
+	p := &fakeProvider{name: "fake", gate: make(chan struct{}), blocked: func(index int) bool { return index >= 2 }}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Download(ctx, p, ep, dir, Options{Workers: 2, Progress: func(done, total int) {
+		if done == 2 {
+			// 后面的页都卡在下载中
+			time.AfterFunc(20*time.Millisecond, cancel)
+		}
+	}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	m, err := LoadEpisodeManifest(dir, ep.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Missing(); !reflect.DeepEqual(got, []int{2, 3, 4, 5}) {
+		t.Errorf("Missing() = %v, want [2 3 4 5]", got)
+	}
+	// 取消的页面没有记为失败
+	for _, page := range m.Pages[2:] {
+		if page.Status != PagePending {
+			t.Errorf("page %d = %+v", page.Index, page)
+		}
+	}
+	if p.finished != 1 {
+		t.Errorf("FinishEpisode called %d times, want 1", p.finished)
+	}
+}
@@ -0,0 +1,62 @@
+package downloader
+
+import (
+	"context"
+	"time"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// State 任务状态
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StatePaused    State = "paused"
+	StateFailed    State = "failed"
+	StateDone      State = "done"
+	StateCancelled State = "cancelled"
+)
+
+// Finished 任务是否已经结束，不会再被调度
+func (s State) Finished() bool {
+	return s == StateDone || s == StateFailed || s == StateCancelled
+}
+
+// Request 加入队列时的参数
+type Request struct {
+	Title  string
+	OutDir string
+	// Format 输出格式，见 exporter.Formats
+	Format string
+	// LeftToRight 打包时按从左向右翻页，默认从右向左
+	LeftToRight bool
+	// Pages 页码选择，如 "1-5,20-"，为空时下载整话
+	Pages string
+	// Template 输出路径模板，见 pathtmpl
+	Template string
+}
+
+// Job 一个下载任务，导出字段会被持久化到队列文件
+type Job struct {
+	ID          string    `json:"id"`
+	Mode        string    `json:"mode"`
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	OutDir      string    `json:"out_dir"`
+	Format      string    `json:"format,omitempty"`
+	LeftToRight bool      `json:"left_to_right,omitempty"`
+	Pages       string    `json:"pages,omitempty"`
+	Template    string    `json:"template,omitempty"`
+	State       State     `json:"state"`
+	Current     int       `json:"current"`
+	Total       int       `json:"total"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// 以下字段只在内存中存在，重启后会按 Mode/URL 重新获取章节
+	episode *provider.Episode
+	cancel  context.CancelFunc
+	pause   chan struct{}
+}
@@ -0,0 +1,182 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"mg-Downloader/internal/fsutil"
+	"mg-Downloader/pkg/exporter"
+	"mg-Downloader/pkg/provider"
+)
+
+// ManifestDir 输出目录中保存清单的文件夹，每一话一个清单，文件名为章节 ID，
+// 多话共用一个输出目录时互不影响
+const ManifestDir = ".mg"
+
+// PageStatus 清单中单页的状态
+type PageStatus string
+
+const (
+	PagePending PageStatus = "pending"
+	PageDone    PageStatus = "done"
+	PageFailed  PageStatus = "failed"
+)
+
+// ManifestPage 清单中的一页
+type ManifestPage struct {
+	Index  int        `json:"index"`
+	Src    string     `json:"src"`
+	File   string     `json:"file,omitempty"`
+	Status PageStatus `json:"status"`
+	SHA256 string     `json:"sha256,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// Manifest 记录一个输出目录里某一话的下载情况，重新下载时据此跳过已完成的页
+type Manifest struct {
+	EpisodeID string    `json:"episode_id"`
+	Mode      string    `json:"mode"`
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Meta 打包时使用的元数据，已下载的散图之后也能导出
+	Meta  exporter.Metadata `json:"meta"`
+	Pages []ManifestPage    `json:"pages"`
+}
+
+// manifestPath 一话的清单路径
+func manifestPath(dir, episodeID string) string {
+	return filepath.Join(dir, ManifestDir, safeName(episodeID)+".json")
+}
+
+// newManifest 按章节的页面列表创建清单
+func newManifest(mode string, ep *provider.Episode) *Manifest {
+	m := &Manifest{
+		EpisodeID: ep.ID,
+		Mode:      mode,
+		URL:       ep.URL,
+		Title:     ep.Title,
+		Meta:      exporter.MetadataFrom(ep),
+		Pages:     make([]ManifestPage, len(ep.Pages)),
+	}
+	for i, page := range ep.Pages {
+		m.Pages[i] = ManifestPage{Index: i, Src: page.Src, Status: PagePending}
+	}
+	return m
+}
+
+// LoadManifest 读取输出目录中的清单，不存在时返回 nil。
+// 目录中只能有一话的清单，有多话时需要用 LoadEpisodeManifest 指定章节。
+func LoadManifest(dir string) (*Manifest, error) {
+	names, err := filepath.Glob(filepath.Join(dir, ManifestDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	switch len(names) {
+	case 0:
+		return nil, nil
+	case 1:
+		return readManifest(names[0])
+	}
+	return nil, fmt.Errorf("%s 中有 %d 话的清单，无法确定打包哪一话", dir, len(names))
+}
+
+// LoadEpisodeManifest 读取输出目录中指定章节的清单，不存在时返回 nil
+func LoadEpisodeManifest(dir, episodeID string) (*Manifest, error) {
+	return readManifest(manifestPath(dir, episodeID))
+}
+
+// readManifest 读取清单文件，不存在时返回 nil
+func readManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取清单失败: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("解析清单失败: %w", err)
+	}
+	return &m, nil
+}
+
+// openManifest 读取已有清单并校验文件，页数变化时重新开始
+func openManifest(dir, mode string, ep *provider.Episode) *Manifest {
+	fresh := newManifest(mode, ep)
+	old, err := LoadEpisodeManifest(dir, ep.ID)
+	if err != nil || old == nil || len(old.Pages) != len(ep.Pages) {
+		return fresh
+	}
+
+	for i, page := range old.Pages {
+		if page.Status != PageDone || page.File == "" {
+			continue
+		}
+		// 文件缺失或校验和不一致的页面重新下载
+		sum, err := fileSHA256(filepath.Join(dir, page.File))
+		if err != nil || sum != page.SHA256 {
+			continue
+		}
+		fresh.Pages[i].File = page.File
+		fresh.Pages[i].SHA256 = page.SHA256
+		fresh.Pages[i].Status = PageDone
+	}
+	return fresh
+}
+
+// Missing 返回还需要下载的页
+func (m *Manifest) Missing() []int {
+	var missing []int
+	for _, page := range m.Pages {
+		if page.Status != PageDone {
+			missing = append(missing, page.Index)
+		}
+	}
+	return missing
+}
+
+// markDone 记录已写入的页
+func (m *Manifest) markDone(index int, file string, data []byte) {
+	sum := sha256.Sum256(data)
+	m.Pages[index].File = file
+	m.Pages[index].SHA256 = hex.EncodeToString(sum[:])
+	m.Pages[index].Status = PageDone
+	m.Pages[index].Error = ""
+}
+
+// markFailed 记录失败的页
+func (m *Manifest) markFailed(index int, err error) {
+	m.Pages[index].Status = PageFailed
+	m.Pages[index].Error = err.Error()
+}
+
+// save 将清单写入输出目录
+func (m *Manifest) save(dir string) error {
+	m.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化清单失败: %w", err)
+	}
+	path := manifestPath(dir, m.EpisodeID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建清单目录失败: %w", err)
+	}
+	return fsutil.WriteFile(path, data, 0644)
+}
+
+func fileSHA256(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
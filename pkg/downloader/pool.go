@@ -0,0 +1,101 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// errPaused 任务被暂停，已派发的页面处理完后返回
+var errPaused = errors.New("任务已暂停")
+
+// pageResult 一页的下载结果
+type pageResult struct {
+	index int
+	img   *provider.Image
+	err   error
+}
+
+// fetchPages 用 workers 个 goroutine 并行下载并解码页面，结果严格按 indexes 的顺序交给 handle。
+// 已下载但还没轮到处理的页面最多缓存 2*workers 张，避免某一页卡住时内存无限增长。
+// stop 关闭后不再派发新页面，已在下载的页面照常处理，然后返回 errPaused。
+func fetchPages(ctx context.Context, p provider.Provider, ep *provider.Episode, indexes []int, workers int, stop <-chan struct{}, handle func(pageResult) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	window := make(chan struct{}, 2*workers)
+	tasks := make(chan int)
+	results := make(chan pageResult)
+
+	// 生产者：按顺序派发页码，窗口满时等待
+	go func() {
+		defer close(tasks)
+		for _, index := range indexes {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			}
+			select {
+			case tasks <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range tasks {
+				img, err := p.FetchPage(ctx, ep, index)
+				select {
+				case results <- pageResult{index: index, img: img, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// 收集者：乱序到达的结果先缓存，按顺序交出
+	pending := make(map[int]pageResult)
+	next := 0
+	for res := range results {
+		pending[res.index] = res
+		for next < len(indexes) {
+			r, ok := pending[indexes[next]]
+			if !ok {
+				break
+			}
+			delete(pending, indexes[next])
+			next++
+			<-window
+			if err := handle(r); err != nil {
+				cancel()
+				return err
+			}
+		}
+	}
+
+	if next < len(indexes) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errPaused
+	}
+	return nil
+}
@@ -0,0 +1,455 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"mg-Downloader/internal/fsutil"
+	"mg-Downloader/pkg/exporter"
+	"mg-Downloader/pkg/pathtmpl"
+	"mg-Downloader/pkg/provider"
+	"mg-Downloader/pkg/selection"
+)
+
+// defaultPageWorkers 未单独配置的站点每个任务并行下载的页数
+const defaultPageWorkers = 4
+
+// Queue 持久化的下载队列，最多同时运行 concurrency 个任务
+type Queue struct {
+	mu          sync.Mutex
+	jobs        []*Job
+	nextID      int64
+	concurrency int
+	running     int
+	pageWorkers map[string]int
+	workers     int
+	ctx         context.Context
+	storePath   string
+	onUpdate    func(Job)
+}
+
+// NewQueue 创建队列并从 storePath 恢复之前保存的任务，onUpdate 在任务状态或进度变化时调用
+func NewQueue(storePath string, concurrency int, onUpdate func(Job)) (*Queue, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	q := &Queue{
+		concurrency: concurrency,
+		pageWorkers: make(map[string]int),
+		workers:     defaultPageWorkers,
+		storePath:   storePath,
+		onUpdate:    onUpdate,
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Start 开始调度队列中的任务，ctx 结束时所有运行中的任务都会停止并在下次启动时继续
+func (q *Queue) Start(ctx context.Context) {
+	q.mu.Lock()
+	q.ctx = ctx
+	q.mu.Unlock()
+	q.schedule()
+}
+
+// SetConcurrency 修改同时运行的任务数
+func (q *Queue) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	q.mu.Lock()
+	q.concurrency = n
+	q.mu.Unlock()
+	q.schedule()
+}
+
+// SetPageWorkers 设置某个站点（按域名）单个任务内并行下载的页数，对之后开始的任务生效
+func (q *Queue) SetPageWorkers(host string, n int) {
+	if n < 1 {
+		n = 1
+	}
+	q.mu.Lock()
+	q.pageWorkers[host] = n
+	q.mu.Unlock()
+}
+
+// SetDefaultPageWorkers 设置未单独配置的站点单个任务内并行下载的页数
+func (q *Queue) SetDefaultPageWorkers(n int) {
+	if n < 1 {
+		n = 1
+	}
+	q.mu.Lock()
+	q.workers = n
+	q.mu.Unlock()
+}
+
+// workersFor 返回章节所在站点的并行页数
+func (q *Queue) workersFor(episodeURL string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if u, err := url.Parse(episodeURL); err == nil {
+		if n, ok := q.pageWorkers[u.Hostname()]; ok {
+			return n
+		}
+	}
+	return q.workers
+}
+
+// Enqueue 将搜索得到的章节加入队列
+func (q *Queue) Enqueue(p provider.Provider, ep *provider.Episode, req Request) (Job, error) {
+	return q.enqueue(p.Name(), ep.URL, ep, req)
+}
+
+// EnqueueURL 按链接将章节加入队列，章节在任务开始时才获取，适合批量下载整部作品
+func (q *Queue) EnqueueURL(mode, episodeURL string, req Request) (Job, error) {
+	if _, err := provider.Get(mode); err != nil {
+		return Job{}, err
+	}
+	return q.enqueue(mode, episodeURL, nil, req)
+}
+
+func (q *Queue) enqueue(mode, episodeURL string, ep *provider.Episode, req Request) (Job, error) {
+	if req.OutDir == "" {
+		return Job{}, fmt.Errorf("未选择路径")
+	}
+	if req.Format != "" && req.Format != exporter.FormatImages {
+		if _, err := exporter.Get(req.Format); err != nil {
+			return Job{}, err
+		}
+	}
+	if req.Pages != "" {
+		if _, err := selection.ParseRanges(req.Pages); err != nil {
+			return Job{}, err
+		}
+	}
+	if _, err := pathtmpl.Parse(req.Template); err != nil {
+		return Job{}, err
+	}
+
+	q.mu.Lock()
+	q.nextID++
+	job := &Job{
+		ID:          strconv.FormatInt(q.nextID, 10),
+		Mode:        mode,
+		URL:         episodeURL,
+		Title:       req.Title,
+		OutDir:      req.OutDir,
+		Format:      req.Format,
+		LeftToRight: req.LeftToRight,
+		Pages:       req.Pages,
+		Template:    req.Template,
+		State:       StateQueued,
+		CreatedAt:   time.Now(),
+		episode:     ep,
+	}
+	if ep != nil {
+		job.Total = len(ep.Pages)
+	}
+	q.jobs = append(q.jobs, job)
+	snapshot := *job
+	q.saveLocked()
+	q.mu.Unlock()
+
+	log.Printf("[Queue] 📥 任务 %s 已加入队列: %s", job.ID, req.Title)
+	q.notify(snapshot)
+	q.schedule()
+	return snapshot, nil
+}
+
+// Cancel 取消任务，运行中的任务会立即中断正在进行的请求
+func (q *Queue) Cancel(id string) error {
+	q.mu.Lock()
+	job := q.findLocked(id)
+	if job == nil {
+		q.mu.Unlock()
+		return fmt.Errorf("任务不存在: %s", id)
+	}
+	if job.State.Finished() {
+		q.mu.Unlock()
+		return nil
+	}
+
+	if job.State == StateRunning {
+		// 由运行中的 goroutine 负责更新状态
+		job.cancel()
+		q.mu.Unlock()
+		return nil
+	}
+
+	job.State = StateCancelled
+	snapshot := *job
+	q.saveLocked()
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	return nil
+}
+
+// Pause 暂停任务：运行中的任务不再派发新页面，已保存的页面保留
+func (q *Queue) Pause(id string) error {
+	q.mu.Lock()
+	job := q.findLocked(id)
+	if job == nil {
+		q.mu.Unlock()
+		return fmt.Errorf("任务不存在: %s", id)
+	}
+
+	switch job.State {
+	case StateRunning:
+		// 由运行中的 goroutine 负责更新状态
+		select {
+		case <-job.pause:
+		default:
+			close(job.pause)
+		}
+		q.mu.Unlock()
+		return nil
+	case StateQueued:
+		job.State = StatePaused
+	default:
+		q.mu.Unlock()
+		return fmt.Errorf("任务当前状态无法暂停: %s", job.State)
+	}
+	snapshot := *job
+	q.saveLocked()
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	return nil
+}
+
+// Resume 继续暂停或失败的任务，从第一张未保存的页面开始
+func (q *Queue) Resume(id string) error {
+	q.mu.Lock()
+	job := q.findLocked(id)
+	if job == nil {
+		q.mu.Unlock()
+		return fmt.Errorf("任务不存在: %s", id)
+	}
+	if job.State != StatePaused && job.State != StateFailed {
+		q.mu.Unlock()
+		return fmt.Errorf("任务当前状态无法继续: %s", job.State)
+	}
+	job.State = StateQueued
+	job.Error = ""
+	snapshot := *job
+	q.saveLocked()
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	q.schedule()
+	return nil
+}
+
+// Remove 从队列中删除已结束的任务
+func (q *Queue) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, job := range q.jobs {
+		if job.ID != id {
+			continue
+		}
+		if !job.State.Finished() {
+			return fmt.Errorf("任务尚未结束，请先取消")
+		}
+		q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+		q.saveLocked()
+		return nil
+	}
+	return fmt.Errorf("任务不存在: %s", id)
+}
+
+// Jobs 返回所有任务的快照
+func (q *Queue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := make([]Job, len(q.jobs))
+	for i, job := range q.jobs {
+		list[i] = *job
+	}
+	return list
+}
+
+// schedule 在有空闲名额时启动排队中的任务
+func (q *Queue) schedule() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ctx == nil {
+		return
+	}
+	changed := false
+	for _, job := range q.jobs {
+		if q.running >= q.concurrency {
+			break
+		}
+		if job.State != StateQueued {
+			continue
+		}
+		job.State = StateRunning
+		job.Error = ""
+		ctx, cancel := context.WithCancel(q.ctx)
+		job.cancel = cancel
+		job.pause = make(chan struct{})
+		q.running++
+		changed = true
+		go q.run(ctx, job)
+	}
+	if changed {
+		q.saveLocked()
+	}
+}
+
+func (q *Queue) run(ctx context.Context, job *Job) {
+	q.mu.Lock()
+	snapshot := *job
+	q.mu.Unlock()
+	q.notify(snapshot)
+
+	err := q.download(ctx, job)
+	// 站点返回的错误不一定包装了 context.Canceled，以任务的 ctx 为准
+	cancelled := ctx.Err() != nil
+
+	q.mu.Lock()
+	q.running--
+	job.cancel()
+	switch {
+	case err == nil:
+		job.State = StateDone
+	case errors.Is(err, errPaused):
+		job.State = StatePaused
+	case q.ctx.Err() != nil:
+		// 应用退出，下次启动时继续
+		job.State = StateQueued
+	case cancelled || errors.Is(err, context.Canceled):
+		job.State = StateCancelled
+	default:
+		job.State = StateFailed
+		job.Error = err.Error()
+	}
+	snapshot = *job
+	q.saveLocked()
+	q.mu.Unlock()
+
+	log.Printf("[Queue] 任务 %s 结束: %s", job.ID, snapshot.State)
+	q.notify(snapshot)
+	q.schedule()
+}
+
+func (q *Queue) download(ctx context.Context, job *Job) error {
+	p, err := provider.Get(job.Mode)
+	if err != nil {
+		return err
+	}
+
+	// 重启后恢复的任务和按链接加入的任务需要先获取章节
+	ep := job.episode
+	if ep == nil {
+		if ep, err = p.FetchEpisode(ctx, job.URL); err != nil {
+			return err
+		}
+		q.mu.Lock()
+		job.episode = ep
+		q.mu.Unlock()
+	}
+
+	var pages []selection.Range
+	if job.Pages != "" {
+		if pages, err = selection.ParseRanges(job.Pages); err != nil {
+			return err
+		}
+	}
+
+	tmpl, err := pathtmpl.Parse(job.Template)
+	if err != nil {
+		return err
+	}
+
+	return Download(ctx, p, ep, job.OutDir, Options{
+		Workers:  q.workersFor(ep.URL),
+		Stop:     job.pause,
+		Format:   job.Format,
+		Export:   exporter.Options{LeftToRight: job.LeftToRight},
+		Pages:    pages,
+		Template: tmpl,
+		Progress: func(done, total int) {
+			q.mu.Lock()
+			job.Current = done
+			job.Total = total
+			snapshot := *job
+			q.mu.Unlock()
+			q.notify(snapshot)
+		},
+	})
+}
+
+func (q *Queue) notify(job Job) {
+	if q.onUpdate != nil {
+		q.onUpdate(job)
+	}
+}
+
+func (q *Queue) findLocked(id string) *Job {
+	for _, job := range q.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// load 读取队列文件，上次退出时仍在运行的任务重新排队
+func (q *Queue) load() error {
+	data, err := os.ReadFile(q.storePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取队列文件失败: %w", err)
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("解析队列文件失败: %w", err)
+	}
+
+	for _, job := range jobs {
+		if job.State == StateRunning {
+			job.State = StateQueued
+		}
+		if id, err := strconv.ParseInt(job.ID, 10, 64); err == nil && id > q.nextID {
+			q.nextID = id
+		}
+	}
+	q.jobs = jobs
+	return nil
+}
+
+// saveLocked 将队列写入磁盘，调用方需持有 q.mu
+func (q *Queue) saveLocked() {
+	if q.storePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(q.jobs, "", "  ")
+	if err != nil {
+		log.Printf("[Queue] ⚠️ 序列化队列失败: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(q.storePath), 0755); err != nil {
+		log.Printf("[Queue] ⚠️ 创建队列目录失败: %v", err)
+		return
+	}
+	if err := fsutil.WriteFile(q.storePath, data, 0644); err != nil {
+		log.Printf("[Queue] ⚠️ 保存队列失败: %v", err)
+	}
+}
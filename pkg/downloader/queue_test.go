@@ -0,0 +1,229 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// queueUpdates 记录队列发出的任务快照
+type queueUpdates chan Job
+
+// waitFor 等待任务 id 的某个快照满足 cond
+func (u queueUpdates) waitFor(t *testing.T, id string, cond func(Job) bool) Job {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case job := <-u:
+			if job.ID == id && cond(job) {
+				return job
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for job %s", id)
+		}
+	}
+}
+
+func inState(state State) func(Job) bool {
+	return func(job Job) bool { return job.State == state }
+}
+
+// registered 已注册的测试站点数，-count 多次运行时站点名也不重复
+var registered atomic.Int32
+
+// registerFake 以唯一的名称注册 fake
+func registerFake(t *testing.T, fake *fakeProvider) {
+	fake.name = fmt.Sprintf("fake-%s-%d", t.Name(), registered.Add(1))
+	provider.Register(fake)
+}
+
+// newTestQueue 注册 fake 后创建并启动一个队列
+func newTestQueue(t *testing.T, fake *fakeProvider, concurrency int) (*Queue, queueUpdates) {
+	t.Helper()
+	registerFake(t, fake)
+
+	updates := make(queueUpdates, 1000)
+	q, err := NewQueue(filepath.Join(t.TempDir(), "queue.json"), concurrency, func(job Job) { updates <- job })
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	q.Start(ctx)
+	return q, updates
+}
+
+func TestQueuePauseResume(t *testing.T) {
+	fake := &fakeProvider{gate: make(chan struct{}), blocked: func(index int) bool { return index >= 2 }}
+	q, updates := newTestQueue(t, fake, 1)
+	dir := t.TempDir()
+	// 页数多于派发窗口，暂停时还有没派发的页面
+	ep := testEpisode("1", 20)
+
+	job, err := q.Enqueue(fake, ep, Request{Title: "test", OutDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates.waitFor(t, job.ID, func(j Job) bool { return j.State == StateRunning && j.Current == 2 })
+
+	if err := q.Pause(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	// 已在下载的页面完成后任务才停下
+	close(fake.gate)
+	paused := updates.waitFor(t, job.ID, inState(StatePaused))
+	if paused.Current >= paused.Total {
+		t.Fatalf("paused job already finished: %+v", paused)
+	}
+	before := fake.fetched()
+
+	if err := q.Resume(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	done := updates.waitFor(t, job.ID, inState(StateDone))
+	if done.Current != 20 || done.Total != 20 {
+		t.Errorf("done job = %+v", done)
+	}
+	checkPages(t, dir, ep)
+
+	// 每页只下载一次
+	want := make([]int, len(ep.Pages))
+	for i := range want {
+		want[i] = i
+	}
+	if got := fake.fetched(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fetched %v (before pausing: %v)", got, before)
+	}
+}
+
+func TestQueueCancelRunning(t *testing.T) {
+	fake := &fakeProvider{gate: make(chan struct{})}
+	q, updates := newTestQueue(t, fake, 1)
+	dir := t.TempDir()
+
+	running, err := q.Enqueue(fake, testEpisode("1", 4), Request{Title: "running", OutDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued, err := q.Enqueue(fake, testEpisode("2", 4), Request{Title: "queued", OutDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates.waitFor(t, running.ID, inState(StateRunning))
+
+	// 排队中的任务直接取消，不会开始下载
+	if err := q.Cancel(queued.ID); err != nil {
+		t.Fatal(err)
+	}
+	updates.waitFor(t, queued.ID, inState(StateCancelled))
+
+	// 运行中的任务中断正在进行的请求
+	if err := q.Cancel(running.ID); err != nil {
+		t.Fatal(err)
+	}
+	cancelled := updates.waitFor(t, running.ID, inState(StateCancelled))
+	if cancelled.Error != "" {
+		t.Errorf("cancelled job has error %q", cancelled.Error)
+	}
+
+	fake.mu.Lock()
+	completed := len(fake.completed)
+	fake.mu.Unlock()
+	if completed != 0 {
+		t.Errorf("%d pages completed after cancelling", completed)
+	}
+	for _, index := range fake.fetched() {
+		if index >= 4 {
+			t.Errorf("fetched page %d", index)
+		}
+	}
+
+	if err := q.Remove(running.ID); err != nil {
+		t.Error(err)
+	}
+	if err := q.Pause(queued.ID); err == nil {
+		t.Error("paused a cancelled job")
+	}
+}
+
+func TestQueuePauseQueued(t *testing.T) {
+	fake := &fakeProvider{gate: make(chan struct{})}
+	q, updates := newTestQueue(t, fake, 1)
+	dir := t.TempDir()
+
+	first, err := q.Enqueue(fake, testEpisode("1", 2), Request{Title: "first", OutDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := q.Enqueue(fake, testEpisode("2", 2), Request{Title: "second", OutDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Pause(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	updates.waitFor(t, second.ID, inState(StatePaused))
+
+	// 第一个任务结束后，暂停的任务不会被调度
+	close(fake.gate)
+	updates.waitFor(t, first.ID, inState(StateDone))
+	for _, job := range q.Jobs() {
+		if job.ID == second.ID && job.State != StatePaused {
+			t.Errorf("paused job was scheduled: %+v", job)
+		}
+	}
+
+	if err := q.Resume(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	updates.waitFor(t, second.ID, inState(StateDone))
+}
+
+func TestQueueRestoresRunningJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	saved := []Job{
+		{ID: "3", Mode: "fake", URL: "https://example.com/episode/1", OutDir: "out", State: StateRunning, Current: 2, Total: 5},
+		{ID: "7", Mode: "fake", URL: "https://example.com/episode/2", OutDir: "out", State: StatePaused},
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有 Start，任务不会开始
+	q, err := NewQueue(path, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := q.Jobs()
+	if len(jobs) != 2 || jobs[0].State != StateQueued || jobs[1].State != StatePaused {
+		t.Fatalf("restored jobs = %+v", jobs)
+	}
+
+	// 新任务的 ID 接着已有的最大 ID
+	job, err := q.EnqueueURL("fake-unknown", "https://example.com/episode/3", Request{OutDir: "out"})
+	if err == nil {
+		t.Fatalf("enqueued a job for an unknown site: %+v", job)
+	}
+	fake := &fakeProvider{}
+	registerFake(t, fake)
+	job, err = q.EnqueueURL(fake.name, "https://example.com/episode/3", Request{OutDir: "out"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != "8" {
+		t.Errorf("new job ID = %s, want 8", job.ID)
+	}
+}
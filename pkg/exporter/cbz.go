@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"mg-Downloader/internal/fsutil"
+)
+
+// comicInfo ComicInfo.xml（Anansi 2.0 schema），Komga/Kavita 会读取其中的元数据
+type comicInfo struct {
+	XMLName     xml.Name        `xml:"ComicInfo"`
+	XSI         string          `xml:"xmlns:xsi,attr"`
+	XSD         string          `xml:"xmlns:xsd,attr"`
+	Title       string          `xml:"Title,omitempty"`
+	Series      string          `xml:"Series,omitempty"`
+	Number      string          `xml:"Number,omitempty"`
+	Writer      string          `xml:"Writer,omitempty"`
+	Publisher   string          `xml:"Publisher,omitempty"`
+	Web         string          `xml:"Web,omitempty"`
+	PageCount   int             `xml:"PageCount"`
+	LanguageISO string          `xml:"LanguageISO,omitempty"`
+	Manga       string          `xml:"Manga"`
+	Pages       []comicInfoPage `xml:"Pages>Page"`
+}
+
+type comicInfoPage struct {
+	Image int    `xml:"Image,attr"`
+	Type  string `xml:"Type,attr,omitempty"`
+}
+
+type cbzExporter struct{}
+
+func init() {
+	Register("cbz", cbzExporter{})
+}
+
+func (cbzExporter) Ext() string {
+	return "cbz"
+}
+
+func (cbzExporter) Export(dst string, meta Metadata, pages []string, opts Options) error {
+	return fsutil.WriteAtomic(dst, 0644, func(f io.Writer) error {
+		zw := zip.NewWriter(f)
+
+		info, err := zw.Create("ComicInfo.xml")
+		if err != nil {
+			return fmt.Errorf("写入ComicInfo.xml失败: %w", err)
+		}
+		if _, err := io.WriteString(info, xml.Header); err != nil {
+			return fmt.Errorf("写入ComicInfo.xml失败: %w", err)
+		}
+		enc := xml.NewEncoder(info)
+		enc.Indent("", "  ")
+		if err := enc.Encode(newComicInfo(meta, len(pages), opts)); err != nil {
+			return fmt.Errorf("写入ComicInfo.xml失败: %w", err)
+		}
+
+		for i, page := range pages {
+			// 图片本身已经压缩，直接存储即可
+			w, err := zw.CreateHeader(&zip.FileHeader{
+				Name:   fmt.Sprintf("%03d%s", i+1, filepath.Ext(page)),
+				Method: zip.Store,
+			})
+			if err != nil {
+				return fmt.Errorf("写入第 %d 页失败: %w", i+1, err)
+			}
+			if err := copyFile(w, page); err != nil {
+				return fmt.Errorf("写入第 %d 页失败: %w", i+1, err)
+			}
+		}
+		return zw.Close()
+	})
+}
+
+func newComicInfo(meta Metadata, pageCount int, opts Options) comicInfo {
+	info := comicInfo{
+		XSI:         "http://www.w3.org/2001/XMLSchema-instance",
+		XSD:         "http://www.w3.org/2001/XMLSchema",
+		Title:       meta.Title,
+		Series:      meta.Series,
+		Writer:      meta.Author,
+		Publisher:   meta.Publisher,
+		Web:         meta.URL,
+		PageCount:   pageCount,
+		LanguageISO: meta.Language,
+		Manga:       "YesAndRightToLeft",
+	}
+	if opts.LeftToRight {
+		info.Manga = "Yes"
+	}
+	if meta.Number > 0 {
+		info.Number = strconv.Itoa(meta.Number)
+	}
+	for i := 0; i < pageCount; i++ {
+		page := comicInfoPage{Image: i}
+		if i == 0 {
+			page.Type = "FrontCover"
+		}
+		info.Pages = append(info.Pages, page)
+	}
+	return info
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,265 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mg-Downloader/internal/fsutil"
+)
+
+// epubExporter 生成固定版式的 EPUB 3，默认从右向左翻页
+type epubExporter struct{}
+
+func init() {
+	Register("epub", epubExporter{})
+}
+
+func (epubExporter) Ext() string {
+	return "epub"
+}
+
+// epubPage 一页在 EPUB 中的图片和 XHTML 文件
+type epubPage struct {
+	id        string
+	image     string
+	xhtml     string
+	mediaType string
+	width     int
+	height    int
+}
+
+// epubFile 写入 EPUB 的文本文件
+type epubFile struct {
+	name string
+	data string
+}
+
+func (epubExporter) Export(dst string, meta Metadata, pages []string, opts Options) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("没有可打包的页面")
+	}
+
+	items := make([]epubPage, len(pages))
+	for i, page := range pages {
+		width, height, err := imageSize(page)
+		if err != nil {
+			return fmt.Errorf("读取第 %d 页尺寸失败: %w", i+1, err)
+		}
+		ext := strings.ToLower(filepath.Ext(page))
+		items[i] = epubPage{
+			id:        fmt.Sprintf("p%03d", i+1),
+			image:     fmt.Sprintf("images/%03d%s", i+1, ext),
+			xhtml:     fmt.Sprintf("p%03d.xhtml", i+1),
+			mediaType: imageMediaType(ext),
+			width:     width,
+			height:    height,
+		}
+	}
+
+	return fsutil.WriteAtomic(dst, 0644, func(f io.Writer) error {
+		zw := zip.NewWriter(f)
+
+		// mimetype 必须是第一个文件，不压缩且不带数据描述符
+		mimetype := []byte("application/epub+zip")
+		w, err := zw.CreateRaw(&zip.FileHeader{
+			Name:               "mimetype",
+			Method:             zip.Store,
+			CRC32:              crc32.ChecksumIEEE(mimetype),
+			CompressedSize64:   uint64(len(mimetype)),
+			UncompressedSize64: uint64(len(mimetype)),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(mimetype); err != nil {
+			return err
+		}
+
+		files := []epubFile{
+			{"META-INF/container.xml", epubContainer},
+			{"OEBPS/content.opf", epubPackage(meta, items, opts)},
+			{"OEBPS/nav.xhtml", epubNav(meta, items)},
+		}
+		for _, item := range items {
+			files = append(files, epubFile{"OEBPS/" + item.xhtml, epubPageXHTML(meta, item)})
+		}
+		for _, file := range files {
+			w, err := zw.Create(file.name)
+			if err != nil {
+				return fmt.Errorf("写入 %s 失败: %w", file.name, err)
+			}
+			if _, err := io.WriteString(w, file.data); err != nil {
+				return fmt.Errorf("写入 %s 失败: %w", file.name, err)
+			}
+		}
+
+		for i, item := range items {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: "OEBPS/" + item.image, Method: zip.Store})
+			if err != nil {
+				return fmt.Errorf("写入第 %d 页失败: %w", i+1, err)
+			}
+			if err := copyFile(w, pages[i]); err != nil {
+				return fmt.Errorf("写入第 %d 页失败: %w", i+1, err)
+			}
+		}
+		return zw.Close()
+	})
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+func epubPackage(meta Metadata, items []epubPage, opts Options) string {
+	first := items[0]
+	direction := "rtl"
+	if opts.LeftToRight {
+		direction = "ltr"
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid" xml:lang="` + esc(meta.Language) + `" prefix="rendition: http://www.idpf.org/vocab/rendition/#">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&b, "    <dc:identifier id=\"bookid\">%s</dc:identifier>\n", esc(bookID(meta)))
+	fmt.Fprintf(&b, "    <dc:title>%s</dc:title>\n", esc(displayTitle(meta)))
+	if meta.Author != "" {
+		fmt.Fprintf(&b, "    <dc:creator>%s</dc:creator>\n", esc(meta.Author))
+	}
+	if meta.Publisher != "" {
+		fmt.Fprintf(&b, "    <dc:publisher>%s</dc:publisher>\n", esc(meta.Publisher))
+	}
+	fmt.Fprintf(&b, "    <dc:language>%s</dc:language>\n", esc(meta.Language))
+	fmt.Fprintf(&b, "    <meta property=\"dcterms:modified\">%s</meta>\n", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	b.WriteString(`    <meta property="rendition:layout">pre-paginated</meta>
+    <meta property="rendition:orientation">auto</meta>
+    <meta property="rendition:spread">landscape</meta>
+    <meta name="cover" content="img-` + first.id + `"/>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+`)
+	for i, item := range items {
+		props := ""
+		if i == 0 {
+			props = ` properties="cover-image"`
+		}
+		fmt.Fprintf(&b, "    <item id=\"img-%s\" href=\"%s\" media-type=\"%s\"%s/>\n", item.id, item.image, item.mediaType, props)
+		fmt.Fprintf(&b, "    <item id=\"%s\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", item.id, item.xhtml)
+	}
+	b.WriteString(`  </manifest>
+  <spine page-progression-direction="` + direction + `">
+`)
+	for _, item := range items {
+		fmt.Fprintf(&b, "    <itemref idref=\"%s\"/>\n", item.id)
+	}
+	b.WriteString(`  </spine>
+</package>
+`)
+	return b.String()
+}
+
+func epubNav(meta Metadata, items []epubPage) string {
+	title := esc(displayTitle(meta))
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + esc(meta.Language) + `">
+<head><title>` + title + `</title></head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <ol>
+      <li><a href="` + items[0].xhtml + `">` + title + `</a></li>
+    </ol>
+  </nav>
+  <nav epub:type="landmarks" hidden="">
+    <ol>
+      <li><a epub:type="cover" href="` + items[0].xhtml + `">表紙</a></li>
+      <li><a epub:type="bodymatter" href="` + items[0].xhtml + `">本文</a></li>
+    </ol>
+  </nav>
+</body>
+</html>
+`
+}
+
+func epubPageXHTML(meta Metadata, item epubPage) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="%s">
+<head>
+  <title>%s</title>
+  <meta name="viewport" content="width=%d, height=%d"/>
+  <style>html, body { margin: 0; padding: 0; } img { width: 100%%; height: 100%%; display: block; }</style>
+</head>
+<body>
+  <img src="%s" alt=""/>
+</body>
+</html>
+`, esc(meta.Language), esc(displayTitle(meta)), item.width, item.height, item.image)
+}
+
+// bookID 根据链接生成稳定的 urn:uuid，同一话重复导出时标识不变
+func bookID(meta Metadata) string {
+	sum := sha1.Sum([]byte(meta.URL + "\x00" + displayTitle(meta)))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// displayTitle 作品名加话名，作为书名
+func displayTitle(meta Metadata) string {
+	switch {
+	case meta.Series != "" && meta.Title != "" && meta.Series != meta.Title:
+		return meta.Series + " " + meta.Title
+	case meta.Title != "":
+		return meta.Title
+	default:
+		return meta.Series
+	}
+}
+
+func imageSize(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+func imageMediaType(ext string) string {
+	switch ext {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".webp":
+		return "image/webp"
+	default:
+		return "image/png"
+	}
+}
+
+func esc(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// FormatImages 不打包，页面以散图保存
+const FormatImages = "images"
+
+// Metadata 打包时写入的元数据
+type Metadata struct {
+	Series    string `json:"series,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Number    int    `json:"number,omitempty"`
+	Author    string `json:"author,omitempty"`
+	Publisher string `json:"publisher,omitempty"`
+	Language  string `json:"language,omitempty"`
+	URL       string `json:"url,omitempty"`
+	PageCount int    `json:"page_count"`
+}
+
+// MetadataFrom 从章节信息生成元数据
+func MetadataFrom(ep *provider.Episode) Metadata {
+	title := ep.EpisodeTitle
+	if title == "" {
+		title = ep.Title
+	}
+	return Metadata{
+		Series:    ep.Series,
+		Title:     title,
+		Number:    ep.Number,
+		Author:    ep.Author,
+		Publisher: ep.Publisher,
+		Language:  "ja",
+		URL:       ep.URL,
+		PageCount: len(ep.Pages),
+	}
+}
+
+// Options 打包选项，零值按日本漫画从右向左翻页
+type Options struct {
+	// LeftToRight 从左向右翻页。EPUB 的翻页方向、CBZ 的 Manga 字段和 PDF 的阅读器偏好都按此写入
+	LeftToRight bool
+}
+
+// Exporter 将下载好的页面打包成单个文件
+type Exporter interface {
+	// Ext 生成文件的扩展名，不含点
+	Ext() string
+	// Export 按 pages 的顺序把页面写入 dst
+	Export(dst string, meta Metadata, pages []string, opts Options) error
+}
+
+var (
+	mu        sync.RWMutex
+	exporters = make(map[string]Exporter)
+)
+
+// Register 注册输出格式
+func Register(format string, e Exporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := exporters[format]; dup {
+		panic("exporter: Register called twice for " + format)
+	}
+	exporters[format] = e
+}
+
+// Get 按格式名获取打包器
+func Get(format string) (Exporter, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("不支持的格式: %s", format)
+	}
+	return e, nil
+}
+
+// Formats 返回所有可用的输出格式，包括散图
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	formats := []string{FormatImages}
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats[1:])
+	return formats
+}
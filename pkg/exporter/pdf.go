@@ -0,0 +1,219 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"mg-Downloader/internal/fsutil"
+)
+
+// pdfExporter 纯 Go 的 PDF 写入器：每页一张图片，页面尺寸等于图片原始像素尺寸。
+// JPEG 原样嵌入（DCTDecode），其他格式解码后以 FlateDecode 压缩的 RGB 嵌入。
+type pdfExporter struct{}
+
+func init() {
+	Register("pdf", pdfExporter{})
+}
+
+func (pdfExporter) Ext() string {
+	return "pdf"
+}
+
+// pdfImage 一页图片在 PDF 中的表示
+type pdfImage struct {
+	width, height int
+	colorSpace    string
+	filter        string
+	data          []byte
+}
+
+func (pdfExporter) Export(dst string, meta Metadata, pages []string, opts Options) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("没有可打包的页面")
+	}
+
+	return fsutil.WriteAtomic(dst, 0644, func(f io.Writer) error {
+		w := &pdfWriter{w: bufio.NewWriter(f)}
+
+		// 对象编号：1 目录，2 页面树，3 文档信息，之后每页依次占用页面、内容流、图片三个对象
+		const firstPageObj = 4
+		kids := make([]string, len(pages))
+		for i := range pages {
+			kids[i] = fmt.Sprintf("%d 0 R", firstPageObj+i*3)
+		}
+
+		w.header()
+
+		// 从右向左翻页时在阅读器偏好中声明，双页显示时右页在前
+		catalog := "<< /Type /Catalog /Pages 2 0 R"
+		if !opts.LeftToRight {
+			catalog += " /ViewerPreferences << /Direction /R2L >>"
+		}
+		w.object(1, catalog+" >>")
+		w.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+		w.object(3, pdfInfo(meta))
+
+		for i, page := range pages {
+			img, err := loadPDFImage(page)
+			if err != nil {
+				return fmt.Errorf("读取第 %d 页失败: %w", i+1, err)
+			}
+			pageObj := firstPageObj + i*3
+			contentObj, imageObj := pageObj+1, pageObj+2
+
+			w.object(pageObj, fmt.Sprintf(
+				"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+				img.width, img.height, imageObj, contentObj))
+
+			content := fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", img.width, img.height)
+			w.stream(contentObj, "", []byte(content))
+
+			dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter %s",
+				img.width, img.height, img.colorSpace, img.filter)
+			w.stream(imageObj, dict, img.data)
+		}
+
+		w.trailer(3)
+		if w.err != nil {
+			return fmt.Errorf("写入PDF失败: %w", w.err)
+		}
+		return w.w.Flush()
+	})
+}
+
+// pdfInfo 文档信息字典
+func pdfInfo(meta Metadata) string {
+	info := "<< /Producer " + pdfText("mg-Downloader")
+	if title := displayTitle(meta); title != "" {
+		info += " /Title " + pdfText(title)
+	}
+	if meta.Author != "" {
+		info += " /Author " + pdfText(meta.Author)
+	}
+	if meta.Series != "" {
+		info += " /Subject " + pdfText(meta.Series)
+	}
+	info += " /CreationDate (" + time.Now().Format("D:20060102150405Z07'00'") + ")"
+	return info + " >>"
+}
+
+// pdfText 以 UTF-16BE 十六进制串表示文本，日文标题也能正确显示
+func pdfText(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// loadPDFImage 读取一页图片，JPEG 直接使用原始数据
+func loadPDFImage(path string) (*pdfImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".jpg" || ext == ".jpeg" {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		colorSpace := "/DeviceRGB"
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			colorSpace = "/DeviceGray"
+		case color.CMYKModel:
+			colorSpace = "/DeviceCMYK"
+		}
+		return &pdfImage{width: cfg.Width, height: cfg.Height, colorSpace: colorSpace, filter: "/DCTDecode", data: data}, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+
+	// 透明部分合成到白底上
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, bounds.Dx()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := src.At(x, y).RGBA()
+			i := (x - bounds.Min.X) * 3
+			row[i] = byte((r + (0xffff - a)) >> 8)
+			row[i+1] = byte((g + (0xffff - a)) >> 8)
+			row[i+2] = byte((b + (0xffff - a)) >> 8)
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &pdfImage{width: bounds.Dx(), height: bounds.Dy(), colorSpace: "/DeviceRGB", filter: "/FlateDecode", data: buf.Bytes()}, nil
+}
+
+// pdfWriter 顺序写出对象并记录偏移量，最后生成交叉引用表
+type pdfWriter struct {
+	w       *bufio.Writer
+	offset  int64
+	offsets map[int]int64
+	err     error
+}
+
+func (w *pdfWriter) write(s string) {
+	w.writeBytes([]byte(s))
+}
+
+func (w *pdfWriter) writeBytes(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	w.err = err
+}
+
+func (w *pdfWriter) header() {
+	w.offsets = make(map[int]int64)
+	w.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+}
+
+func (w *pdfWriter) object(num int, body string) {
+	w.offsets[num] = w.offset
+	w.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", num, body))
+}
+
+func (w *pdfWriter) stream(num int, dict string, data []byte) {
+	w.offsets[num] = w.offset
+	w.write(fmt.Sprintf("%d 0 obj\n<< %s /Length %d >>\nstream\n", num, dict, len(data)))
+	w.writeBytes(data)
+	w.write("\nendstream\nendobj\n")
+}
+
+func (w *pdfWriter) trailer(infoObj int) {
+	size := len(w.offsets) + 1
+	xref := w.offset
+	w.write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", size))
+	for num := 1; num < size; num++ {
+		w.write(fmt.Sprintf("%010d 00000 n \n", w.offsets[num]))
+	}
+	w.write(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, infoObj, xref))
+}
@@ -0,0 +1,37 @@
+package gigaviewer
+
+import (
+	"log"
+
+	"mg-Downloader/pkg/cookies"
+	"mg-Downloader/pkg/httpx"
+	"mg-Downloader/pkg/provider"
+)
+
+// NewClient 按站点的网络设置创建使用 jar 的 HTTP 客户端
+func NewClient(s provider.Settings, jar *cookies.Store) *httpx.Client {
+	return httpx.New(httpx.Options{
+		Jar:        jar,
+		Timeout:    s.Timeout,
+		MaxRetries: s.MaxRetries,
+		BaseDelay:  s.RetryDelay,
+		Limit:      httpx.Limit{Rate: s.RateLimit, MaxConns: s.MaxConns},
+		Proxy:      s.Proxy,
+	})
+}
+
+// OpenCookies 读取 cookie 文件，文件无法解析时记录日志并当作没有 cookie
+func OpenCookies(file string) *cookies.Store {
+	jar, err := cookies.Shared(file)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return jar
+}
+
+// SaveCookies 把站点刷新的 cookie 写回文件
+func SaveCookies(jar *cookies.Store) {
+	if err := jar.Save(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
@@ -1,10 +1,10 @@
-package ourfeel
+package gigaviewer
 
 import (
 	"image"
 	"image/draw"
 	"image/png"
-	"os"
+	"io"
 )
 
 type ImageProcessor struct {
@@ -47,13 +47,8 @@ func (ip *ImageProcessor) Deobfuscate(width, height int) *image.RGBA {
 	return ip.Dst
 }
 
-func (ip *ImageProcessor) SaveImage(filePath string) error {
-	outFile, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer outFile.Close()
-	return png.Encode(outFile, ip.Dst)
+func (ip *ImageProcessor) Encode(w io.Writer) error {
+	return png.Encode(w, ip.Dst)
 }
 
 func (ip *ImageProcessor) RestoreRightTransparentStrip(width, height, stripWidth int) {
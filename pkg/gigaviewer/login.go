@@ -0,0 +1,219 @@
+// Package gigaviewer 是 comic-days、ourfeel 等 GigaViewer 站点共用的实现：章节下载、登录、作品目录和网络设置。
+// 各站点只需用 Register 注册一个 Site；站点地址由调用方传入，可以指向本地的测试服务器。
+package gigaviewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"mg-Downloader/pkg/cookies"
+	"mg-Downloader/pkg/httpx"
+	"mg-Downloader/pkg/provider"
+)
+
+// SessionCookie 保存登录状态的 cookie
+const SessionCookie = "glsc"
+
+const (
+	// accountPath 账号页，未登录时跳转到登录页
+	accountPath = "/user_account"
+	// loginPath 登录页
+	loginPath = "/user_account/login"
+)
+
+// ErrLoginFailed 站点没有接受用户名和密码
+var ErrLoginFailed = errors.New("登录失败，用户名或密码错误")
+
+// LoginError 在无法读取章节时检查登录 cookie：没有登录 cookie 或已过期时返回
+// 包装了 provider.ErrNotLoggedIn 或 provider.ErrCookieExpired 的错误，否则原样返回 err。
+// 只读取 cookie 文件，不访问站点。
+func LoginError(jar *cookies.Store, err error) error {
+	if _, serr := jar.Session(SessionCookie); serr != nil {
+		return fmt.Errorf("%w (%v)", serr, err)
+	}
+	return err
+}
+
+// CheckLogin 检查 jar 中的登录 cookie，并用它访问 base 站点的账号页确认仍然有效。
+// client 需要使用 jar 发送 cookie，站点刷新的 cookie 会记录到 jar 中。
+func CheckLogin(ctx context.Context, client *httpx.Client, jar *cookies.Store, base string) (*provider.LoginStatus, error) {
+	status := &provider.LoginStatus{}
+	session, err := jar.Session(SessionCookie)
+	if err != nil {
+		return status, err
+	}
+	status.Expires = session.Expires()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(base, "/")+accountPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if httpx.IsStatus(err, http.StatusUnauthorized) || httpx.IsStatus(err, http.StatusForbidden) {
+			return status, fmt.Errorf("%w: 站点拒绝了保存的登录 cookie", provider.ErrCookieExpired)
+		}
+		return nil, fmt.Errorf("访问账号页失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 登录失效时账号页跳转到登录页
+	if isLoginPage(resp.Request.URL) {
+		return status, fmt.Errorf("%w: 站点不再接受保存的登录 cookie", provider.ErrCookieExpired)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("解析账号页失败: %w", err)
+	}
+	status.LoggedIn = true
+	status.User = userName(doc)
+	return status, nil
+}
+
+// isLoginPage 判断是否被跳转到了登录页
+func isLoginPage(u *url.URL) bool {
+	return u != nil && strings.HasPrefix(u.Path, loginPath)
+}
+
+// userName 账号页中显示的用户名，找不到时返回空字符串
+func userName(doc *goquery.Document) string {
+	for _, sel := range []string{".user-account-name", ".js-user-name", ".user-name"} {
+		if name := strings.TrimSpace(doc.Find(sel).First().Text()); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Login 用用户名（邮箱）和密码登录 base 站点：读取登录页表单中的 CSRF token，提交表单，
+// 站点设置的登录 cookie 记录到 jar 中，最后访问账号页确认登录成功。
+// client 需要使用 jar，保存 cookie 文件由调用方负责。
+func Login(ctx context.Context, client *httpx.Client, jar *cookies.Store, base, user, password string) (*provider.LoginStatus, error) {
+	if user == "" || password == "" {
+		return nil, fmt.Errorf("需要用户名和密码")
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	page, doc, err := fetchDocument(ctx, client, base+loginPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开登录页失败: %w", err)
+	}
+	form, err := parseLoginForm(doc, page)
+	if err != nil {
+		return nil, err
+	}
+	form.values.Set(form.userField, user)
+	form.values.Set(form.passwordField, password)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", form.action, strings.NewReader(form.values.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Origin", page.Scheme+"://"+page.Host)
+	req.Header.Set("Referer", page.String())
+	if form.csrfHeader != "" {
+		req.Header.Set("X-CSRF-Token", form.csrfHeader)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity} {
+			if httpx.IsStatus(err, code) {
+				return nil, ErrLoginFailed
+			}
+		}
+		return nil, fmt.Errorf("提交登录表单失败: %w", err)
+	}
+	resp.Body.Close()
+	// 登录失败时站点重新显示登录页
+	if isLoginPage(resp.Request.URL) {
+		return nil, ErrLoginFailed
+	}
+
+	status, err := CheckLogin(ctx, client, jar, base)
+	if provider.IsLoginError(err) {
+		return nil, fmt.Errorf("%w: %v", ErrLoginFailed, err)
+	}
+	return status, err
+}
+
+// loginForm 登录页中的表单
+type loginForm struct {
+	action        string
+	values        url.Values
+	userField     string
+	passwordField string
+	// csrfHeader 页面 meta 中的 CSRF token，随请求头发送
+	csrfHeader string
+}
+
+// parseLoginForm 找到包含密码输入框的表单，保留其中的隐藏字段（包括 CSRF token）
+func parseLoginForm(doc *goquery.Document, page *url.URL) (*loginForm, error) {
+	password := doc.Find(`form input[type="password"]`).First()
+	if password.Length() == 0 {
+		return nil, fmt.Errorf("登录页中没有找到登录表单")
+	}
+	formSel := password.Closest("form")
+
+	form := &loginForm{
+		values:        url.Values{},
+		passwordField: password.AttrOr("name", ""),
+		csrfHeader:    doc.Find(`meta[name="csrf-token"]`).AttrOr("content", ""),
+	}
+	hasToken := form.csrfHeader != ""
+	formSel.Find("input").Each(func(_ int, in *goquery.Selection) {
+		name := in.AttrOr("name", "")
+		if name == "" {
+			return
+		}
+		switch strings.ToLower(in.AttrOr("type", "text")) {
+		case "hidden":
+			form.values.Set(name, in.AttrOr("value", ""))
+			lower := strings.ToLower(name)
+			if strings.Contains(lower, "csrf") || strings.Contains(lower, "token") {
+				hasToken = true
+			}
+		case "email", "text":
+			if form.userField == "" {
+				form.userField = name
+			}
+		}
+	})
+	if form.passwordField == "" || form.userField == "" {
+		return nil, fmt.Errorf("登录页中没有找到用户名或密码输入框")
+	}
+	if !hasToken {
+		return nil, fmt.Errorf("登录页中没有找到 CSRF token")
+	}
+
+	action, err := page.Parse(formSel.AttrOr("action", ""))
+	if err != nil {
+		return nil, fmt.Errorf("登录表单地址无效: %w", err)
+	}
+	form.action = action.String()
+	return form, nil
+}
+
+// fetchDocument 获取并解析 HTML 页面，返回跳转后的地址
+func fetchDocument(ctx context.Context, client *httpx.Client, rawURL string) (*url.URL, *goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析页面失败: %w", err)
+	}
+	return resp.Request.URL, doc, nil
+}
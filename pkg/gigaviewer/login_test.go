@@ -0,0 +1,203 @@
+package gigaviewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"mg-Downloader/pkg/cookies"
+	"mg-Downloader/pkg/httpx"
+	"mg-Downloader/pkg/provider"
+)
+
+const (
+	testUser     = "me@example.com"
+	testPassword = "secret"
+	testToken    = "token-123"
+)
+
+// fakeSite 模拟 GigaViewer 站点的登录页、登录接口和账号页
+type fakeSite struct {
+	// postStatus 不为 0 时登录接口直接返回该状态码
+	postStatus int
+	// metaToken 为 true 时 CSRF token 放在 meta 中并要求随请求头发送，否则放在隐藏字段中
+	metaToken bool
+}
+
+func (f *fakeSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == loginPath && r.Method == "GET":
+		fmt.Fprint(w, f.loginPage())
+	case r.URL.Path == loginPath && r.Method == "POST":
+		f.login(w, r)
+	case r.URL.Path == accountPath:
+		if c, err := r.Cookie(SessionCookie); err != nil || c.Value != "valid" {
+			http.Redirect(w, r, loginPath, http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, `<html><body><span class="user-account-name"> me </span></body></html>`)
+	default:
+		fmt.Fprint(w, `<html><body>top</body></html>`)
+	}
+}
+
+func (f *fakeSite) loginPage() string {
+	head, hidden := "", fmt.Sprintf(`<input type="hidden" name="authenticity_token" value="%s">`, testToken)
+	if f.metaToken {
+		head, hidden = fmt.Sprintf(`<meta name="csrf-token" content="%s">`, testToken), ""
+	}
+	return `<html><head>` + head + `</head><body>
+<form class="search" action="/search"><input type="text" name="q"></form>
+<form action="` + loginPath + `" method="post">` + hidden + `
+<input type="email" name="email">
+<input type="password" name="password">
+<input type="submit" value="login">
+</form></body></html>`
+}
+
+func (f *fakeSite) login(w http.ResponseWriter, r *http.Request) {
+	if f.postStatus != 0 {
+		w.WriteHeader(f.postStatus)
+		return
+	}
+	token := r.PostFormValue("authenticity_token")
+	if f.metaToken {
+		token = r.Header.Get("X-CSRF-Token")
+	}
+	if token != testToken {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	// 与站点相同，密码错误时重新显示登录页
+	if r.PostFormValue("email") != testUser || r.PostFormValue("password") != testPassword {
+		http.Redirect(w, r, loginPath, http.StatusFound)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: "valid", Path: "/", MaxAge: 3600})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// newTestClient 启动 site 并返回使用临时 cookie 文件的客户端
+func newTestClient(t *testing.T, site http.Handler) (*httptest.Server, *httpx.Client, *cookies.Store) {
+	t.Helper()
+	srv := httptest.NewServer(site)
+	t.Cleanup(srv.Close)
+	jar, err := cookies.Shared(filepath.Join(t.TempDir(), "cookie.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, httpx.New(httpx.Options{Jar: jar, MaxRetries: -1}), jar
+}
+
+func TestParseLoginForm(t *testing.T) {
+	page, _ := url.Parse("https://comic-days.com/user_account/login")
+	for _, metaToken := range []bool{false, true} {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader((&fakeSite{metaToken: metaToken}).loginPage()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		form, err := parseLoginForm(doc, page)
+		if err != nil {
+			t.Fatalf("metaToken=%v: %v", metaToken, err)
+		}
+		if form.action != "https://comic-days.com/user_account/login" {
+			t.Errorf("metaToken=%v: action = %q", metaToken, form.action)
+		}
+		if form.userField != "email" || form.passwordField != "password" {
+			t.Errorf("metaToken=%v: fields = %q, %q", metaToken, form.userField, form.passwordField)
+		}
+		if form.values.Has("q") {
+			t.Errorf("metaToken=%v: picked up a field from another form", metaToken)
+		}
+		if metaToken {
+			if form.csrfHeader != testToken {
+				t.Errorf("csrfHeader = %q, want %q", form.csrfHeader, testToken)
+			}
+		} else if got := form.values.Get("authenticity_token"); got != testToken {
+			t.Errorf("authenticity_token = %q, want %q", got, testToken)
+		}
+	}
+}
+
+func TestParseLoginFormWithoutToken(t *testing.T) {
+	page, _ := url.Parse("https://comic-days.com/user_account/login")
+	html := `<form action="/user_account/login"><input type="email" name="email"><input type="password" name="password"></form>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseLoginForm(doc, page); err == nil {
+		t.Fatal("expected an error for a form without a CSRF token")
+	}
+}
+
+func TestLogin(t *testing.T) {
+	for _, metaToken := range []bool{false, true} {
+		srv, client, jar := newTestClient(t, &fakeSite{metaToken: metaToken})
+		status, err := Login(context.Background(), client, jar, srv.URL, testUser, testPassword)
+		if err != nil {
+			t.Fatalf("metaToken=%v: %v", metaToken, err)
+		}
+		if !status.LoggedIn || status.User != "me" {
+			t.Errorf("metaToken=%v: status = %+v", metaToken, status)
+		}
+		session, err := jar.Session(SessionCookie)
+		if err != nil {
+			t.Fatalf("metaToken=%v: %v", metaToken, err)
+		}
+		if session.Value != "valid" {
+			t.Errorf("metaToken=%v: %s = %q", metaToken, SessionCookie, session.Value)
+		}
+	}
+}
+
+func TestLoginFailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		site     *fakeSite
+		password string
+	}{
+		{"wrong password", &fakeSite{}, "wrong"},
+		{"unprocessable entity", &fakeSite{postStatus: http.StatusUnprocessableEntity}, testPassword},
+	}
+	for _, tt := range tests {
+		srv, client, jar := newTestClient(t, tt.site)
+		_, err := Login(context.Background(), client, jar, srv.URL, testUser, tt.password)
+		if !errors.Is(err, ErrLoginFailed) {
+			t.Errorf("%s: err = %v, want ErrLoginFailed", tt.name, err)
+		}
+		if _, err := jar.Session(SessionCookie); err == nil {
+			t.Errorf("%s: got a session cookie", tt.name)
+		}
+	}
+}
+
+func TestCheckLoginExpired(t *testing.T) {
+	srv, client, jar := newTestClient(t, &fakeSite{})
+	u, _ := url.Parse(srv.URL)
+	jar.SetCookies(u, []*http.Cookie{{Name: SessionCookie, Value: "stale", Path: "/", MaxAge: 3600}})
+
+	status, err := CheckLogin(context.Background(), client, jar, srv.URL)
+	if !errors.Is(err, provider.ErrCookieExpired) {
+		t.Fatalf("err = %v, want ErrCookieExpired", err)
+	}
+	if status == nil || status.LoggedIn {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestCheckLoginWithoutCookie(t *testing.T) {
+	srv, client, jar := newTestClient(t, &fakeSite{})
+	_, err := CheckLogin(context.Background(), client, jar, srv.URL)
+	if !errors.Is(err, provider.ErrNotLoggedIn) {
+		t.Fatalf("err = %v, want ErrNotLoggedIn", err)
+	}
+}
@@ -0,0 +1,72 @@
+package gigaviewer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"net/http"
+
+	"github.com/disintegration/imaging"
+
+	"mg-Downloader/pkg/httpx"
+)
+
+// Page 章节中的一页，Width、Height 是解混淆后的图片尺寸
+type Page struct {
+	Src    string `json:"src"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+func NewPage(src string, width, height int) Page {
+	return Page{
+		Src:    src,
+		Width:  width,
+		Height: height,
+	}
+}
+
+// Render 下载页面，返回解混淆后 PNG 编码的图片。referer 是章节页地址，pageNum 只用于错误信息
+func (p Page) Render(ctx context.Context, client *httpx.Client, referer string, pageNum int) ([]byte, error) {
+	img, err := p.download(ctx, client, referer, pageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := p.deobfuscate(img).Encode(&buf); err != nil {
+		return nil, fmt.Errorf("编码第 %d 页失败: %w", pageNum, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// download 下载一次页面：临时错误由客户端重试，仍然失败的页面记录下来之后继续下载
+func (p Page) download(ctx context.Context, client *httpx.Client, referer string, pageNum int) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.Src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建第 %d 页的请求失败: %w", pageNum, err)
+	}
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)")
+	req.Header.Set("Referer", referer)
+
+	data, err := client.Fetch(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载第 %d 页失败: %w", pageNum, err)
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解码第 %d 页失败: %w", pageNum, err)
+	}
+	return img, nil
+}
+
+func (p Page) deobfuscate(img image.Image) *ImageProcessor {
+	imageCtx := NewImageContext(img)
+	imageCtx.Deobfuscate(p.Width, p.Height)
+	rightTransparentWidth := imageCtx.DetectTransparentStripWidth()
+	imageCtx.RestoreRightTransparentStrip(p.Width, p.Height, rightTransparentWidth)
+	return imageCtx
+}
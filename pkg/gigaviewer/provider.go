@@ -0,0 +1,183 @@
+package gigaviewer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// Site 一个 GigaViewer 站点，各站点只有这些不同，新增站点时用 Register 注册即可
+type Site struct {
+	// Name 站点标识，与前端的 mode 一致
+	Name string
+	// BaseURL 站点根地址，如 https://comic-days.com
+	BaseURL string
+	// Host 章节链接的域名，导入 cookie 时也只保留该域名下的 cookie
+	Host string
+	// CookieFile 默认的 cookie 文件路径
+	CookieFile string
+	// Publisher 打包时写入的出版社
+	Publisher string
+}
+
+// Register 注册一个 GigaViewer 站点
+func Register(site Site) {
+	provider.Register(New(site))
+}
+
+// New 创建 site 的 Provider，支持作品目录、cookie 登录检查和账号密码登录
+func New(site Site) provider.Provider {
+	p := &siteProvider{site: site}
+	p.settings = p.DefaultSettings()
+	return p
+}
+
+type siteProvider struct {
+	site Site
+
+	mu       sync.RWMutex
+	settings provider.Settings
+}
+
+// DefaultSettings 返回站点的默认参数
+func (p *siteProvider) DefaultSettings() provider.Settings {
+	return provider.Settings{
+		Timeout:    15 * time.Second,
+		MaxRetries: 5,
+		RetryDelay: 1 * time.Second,
+		RateLimit:  4,
+		MaxConns:   4,
+		CookieFile: p.site.CookieFile,
+	}
+}
+
+// Settings 返回当前生效的参数
+func (p *siteProvider) Settings() provider.Settings {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.settings
+}
+
+// Configure 应用参数，未设置的字段使用默认值
+func (p *siteProvider) Configure(s provider.Settings) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.settings = s.Or(p.DefaultSettings())
+}
+
+func (p *siteProvider) Name() string {
+	return p.site.Name
+}
+
+// CookieDomains 导入 cookie 时只保留这些域名下的 cookie
+func (p *siteProvider) CookieDomains() []string {
+	return []string{p.site.Host}
+}
+
+// CheckLogin 用保存的登录 cookie 访问账号页确认仍然有效
+func (p *siteProvider) CheckLogin(ctx context.Context) (*provider.LoginStatus, error) {
+	settings := p.Settings()
+	jar := OpenCookies(settings.CookieFile)
+	defer SaveCookies(jar)
+	return CheckLogin(ctx, NewClient(settings, jar), jar, p.site.BaseURL)
+}
+
+// Login 用账号密码登录并把登录 cookie 保存到 cookie 文件，不需要再从浏览器导出 cookie
+func (p *siteProvider) Login(ctx context.Context, user, password string) (*provider.LoginStatus, error) {
+	settings := p.Settings()
+	jar := OpenCookies(settings.CookieFile)
+	status, err := Login(ctx, NewClient(settings, jar), jar, p.site.BaseURL, user, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := jar.Save(); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (p *siteProvider) Match(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Hostname() == p.site.Host && strings.Contains(u.Path, "/episode/")
+}
+
+// MatchSeries GigaViewer 没有单独的作品页，章节链接即可列出整部作品
+func (p *siteProvider) MatchSeries(rawURL string) bool {
+	return p.Match(rawURL)
+}
+
+// FetchSeries 列出章节所属作品的全部章节
+func (p *siteProvider) FetchSeries(ctx context.Context, rawURL string) (*provider.Series, error) {
+	settings := p.Settings()
+	jar := OpenCookies(settings.CookieFile)
+	defer SaveCookies(jar)
+	return FetchSeries(ctx, NewClient(settings, jar), rawURL)
+}
+
+func (p *siteProvider) FetchEpisode(ctx context.Context, rawURL string) (*provider.Episode, error) {
+	settings := p.Settings()
+	jar := OpenCookies(settings.CookieFile)
+	defer SaveCookies(jar)
+	title, session, err := NewComicSession(ctx, rawURL, jar, NewClient(settings, jar))
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]provider.Page, len(session.Pages))
+	for i, page := range session.Pages {
+		pages[i] = provider.Page{Index: i, Src: page.Src, Width: page.Width, Height: page.Height}
+	}
+
+	return &provider.Episode{
+		ID:    episodeID(rawURL),
+		URL:   rawURL,
+		Title: title,
+		Pages: pages,
+		Data:  session,
+
+		Thumbnail: session.Thumbnail(),
+
+		Series:       session.Info.Series,
+		EpisodeTitle: session.Info.Title,
+		Number:       session.Info.Number,
+		Author:       session.Info.Author,
+		Publisher:    p.site.Publisher,
+	}, nil
+}
+
+func (p *siteProvider) FetchPage(ctx context.Context, ep *provider.Episode, index int) (*provider.Image, error) {
+	session, ok := ep.Data.(*ComicSession)
+	if !ok {
+		return nil, fmt.Errorf("章节数据不属于%s", p.site.Name)
+	}
+	data, err := session.RenderPage(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.Image{Data: data, Ext: "png"}, nil
+}
+
+// FinishEpisode 一话下载结束后保存期间刷新的 cookie，而不是每页保存一次
+func (*siteProvider) FinishEpisode(ep *provider.Episode) {
+	if session, ok := ep.Data.(*ComicSession); ok {
+		SaveCookies(session.Cookies)
+	}
+}
+
+// episodeID 返回 /episode/<id> 链接的最后一段
+func episodeID(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return path.Base(u.Path)
+}
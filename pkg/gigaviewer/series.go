@@ -0,0 +1,221 @@
+package gigaviewer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"mg-Downloader/pkg/httpx"
+	"mg-Downloader/pkg/provider"
+)
+
+// maxSeriesPages 章节列表最多翻的页数
+const maxSeriesPages = 100
+
+// readableProducts 章节列表接口的响应
+type readableProducts struct {
+	HTML    string `json:"html"`
+	NextURL string `json:"nextUrl"`
+}
+
+// EpisodeJSON 读取章节页中 #episode-json 的数据
+func EpisodeJSON(doc *goquery.Document) (string, error) {
+	jsonData, exists := doc.Find("#episode-json").Attr("data-value")
+	if !exists {
+		return "", fmt.Errorf("章节页中没有章节数据")
+	}
+	jsonData = html.UnescapeString(jsonData)
+	if jsonData == "" {
+		return "", fmt.Errorf("章节数据为空")
+	}
+	return jsonData, nil
+}
+
+// FetchSeries 列出 rawURL 所属作品的全部章节。GigaViewer 没有单独的作品页，作品中任意一话的链接都可以。
+func FetchSeries(ctx context.Context, client *httpx.Client, rawURL string) (*provider.Series, error) {
+	page, doc, err := fetchDocument(ctx, client, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("获取章节页失败: %w", err)
+	}
+	seriesID, err := extractSeriesID(doc)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fetchEpisodeList(ctx, page, seriesID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.Series{
+		ID:       seriesID,
+		URL:      rawURL,
+		Title:    strings.TrimSpace(doc.Find(".series-header-title").First().Text()),
+		Author:   strings.TrimSpace(doc.Find(".series-header-author").First().Text()),
+		Episodes: entries,
+	}, nil
+}
+
+// extractSeriesID 从章节页读取作品 ID
+func extractSeriesID(doc *goquery.Document) (string, error) {
+	if id, ok := doc.Find(".js-valve").Attr("data-giga_series"); ok && id != "" {
+		return id, nil
+	}
+
+	jsonData, err := EpisodeJSON(doc)
+	if err != nil {
+		return "", err
+	}
+	var data struct {
+		ReadableProduct struct {
+			Series struct {
+				ID string `json:"id"`
+			} `json:"series"`
+		} `json:"readableProduct"`
+	}
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return "", fmt.Errorf("解析章节数据失败: %w", err)
+	}
+	if data.ReadableProduct.Series.ID == "" {
+		return "", fmt.Errorf("章节页中没有作品 ID")
+	}
+	return data.ReadableProduct.Series.ID, nil
+}
+
+// fetchEpisodeList 从最新一话开始向前翻章节列表接口，返回从旧到新排列的章节
+func fetchEpisodeList(ctx context.Context, base *url.URL, seriesID string, client *httpx.Client) ([]provider.SeriesEpisode, error) {
+	api := &url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/api/viewer/readable_products"}
+	query := url.Values{}
+	query.Set("aggregate_id", seriesID)
+	query.Set("number_since", "99999")
+	query.Set("number_until", "-1")
+	query.Set("read_more_num", "150")
+	query.Set("type", "episode")
+	api.RawQuery = query.Encode()
+
+	var newestFirst []provider.SeriesEpisode
+	seen := make(map[string]bool)
+	next := api.String()
+	for i := 0; next != "" && i < maxSeriesPages; i++ {
+		page, err := fetchReadableProducts(ctx, next, client)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := parseEpisodeList(page.HTML, base)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, entry := range entries {
+			if seen[entry.URL] {
+				continue
+			}
+			seen[entry.URL] = true
+			newestFirst = append(newestFirst, entry)
+			added++
+		}
+		if added == 0 {
+			break
+		}
+		next = page.NextURL
+	}
+
+	episodes := make([]provider.SeriesEpisode, len(newestFirst))
+	for i, entry := range newestFirst {
+		episodes[len(newestFirst)-1-i] = entry
+	}
+	return episodes, nil
+}
+
+// episodeNumberPatterns 章节标题中话数的写法，按顺序尝试
+var episodeNumberPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`第\s*(\d+)\s*[話话回]`),
+	regexp.MustCompile(`(?i)(?:\bepisode|\bep\.?|#)\s*(\d+)`),
+	regexp.MustCompile(`^\s*(\d+)\s*(?:[話话回]|$|[\s:：.．])`),
+	regexp.MustCompile(`その\s*(\d+)`),
+}
+
+// episodeNumber 从章节标题中解析站点标注的话数，如 "第12話"、"#12"、"12話 タイトル"。
+// 番外篇等没有话数的章节返回 0，按话数选择时不会被选中。
+func episodeNumber(title string) int {
+	// 全角数字转为半角
+	title = strings.Map(func(r rune) rune {
+		if r >= '０' && r <= '９' {
+			return r - '０' + '0'
+		}
+		return r
+	}, title)
+	for _, re := range episodeNumberPatterns {
+		m := re.FindStringSubmatch(title)
+		if m == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+func fetchReadableProducts(ctx context.Context, apiURL string, client *httpx.Client) (*readableProducts, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	data, err := client.Fetch(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取章节列表失败: %w", err)
+	}
+
+	var page readableProducts
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("解析章节列表失败: %w", err)
+	}
+	return &page, nil
+}
+
+// parseEpisodeList 解析章节列表接口返回的 HTML 片段
+func parseEpisodeList(fragment string, base *url.URL) ([]provider.SeriesEpisode, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return nil, fmt.Errorf("解析章节列表失败: %w", err)
+	}
+
+	var entries []provider.SeriesEpisode
+	doc.Find("li.episode").Each(func(_ int, li *goquery.Selection) {
+		href, ok := li.Find("a.series-episode-list-container").Attr("href")
+		if !ok || href == "" {
+			return
+		}
+		ref, err := base.Parse(href)
+		if err != nil {
+			return
+		}
+
+		title := strings.TrimSpace(li.Find(".series-episode-list-title").First().Text())
+		entry := provider.SeriesEpisode{
+			ID:     path.Base(ref.Path),
+			URL:    ref.String(),
+			Number: episodeNumber(title),
+			Title:  title,
+			Free:   li.Find(".series-episode-list-is-free").Length() > 0,
+		}
+		date := strings.TrimSpace(li.Find(".series-episode-list-date").First().Text())
+		if t, err := time.Parse("2006/01/02", date); err == nil {
+			entry.PublishedAt = t
+		}
+		entries = append(entries, entry)
+	})
+	return entries, nil
+}
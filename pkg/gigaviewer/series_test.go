@@ -0,0 +1,63 @@
+package gigaviewer
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEpisodeNumber(t *testing.T) {
+	tests := []struct {
+		title string
+		want  int
+	}{
+		{"第1話", 1},
+		{"第 12 話 はじまり", 12},
+		{"第１２話", 12},
+		{"第3回", 3},
+		{"#45", 45},
+		{"Episode 7", 7},
+		{"ep.8 後編", 8},
+		{"10話", 10},
+		{"11 タイトル", 11},
+		{"その5", 5},
+		{"番外編", 0},
+		{"Step 3", 0},
+		{"2024年 特別編", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := episodeNumber(tt.title); got != tt.want {
+			t.Errorf("episodeNumber(%q) = %d, want %d", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestParseEpisodeList(t *testing.T) {
+	base, _ := url.Parse("https://comic-days.com/episode/1")
+	fragment := `<ul>
+<li class="episode"><a class="series-episode-list-container" href="/episode/30">
+  <h4 class="series-episode-list-title">第3話</h4><span class="series-episode-list-date">2024/01/15</span>
+  <span class="series-episode-list-is-free">無料</span></a></li>
+<li class="episode"><a class="series-episode-list-container" href="/episode/25">
+  <h4 class="series-episode-list-title">番外編</h4></a></li>
+<li class="episode"><h4 class="series-episode-list-title">リンクなし</h4></li>
+</ul>`
+	entries, err := parseEpisodeList(fragment, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	first := entries[0]
+	if first.ID != "30" || first.URL != "https://comic-days.com/episode/30" || first.Number != 3 || !first.Free {
+		t.Errorf("first = %+v", first)
+	}
+	if first.PublishedAt.Format("2006-01-02") != "2024-01-15" {
+		t.Errorf("PublishedAt = %v", first.PublishedAt)
+	}
+	// 番外篇没有话数，不会被当成列表中的位置
+	if extra := entries[1]; extra.Number != 0 || extra.Free {
+		t.Errorf("extra = %+v", extra)
+	}
+}
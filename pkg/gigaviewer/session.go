@@ -0,0 +1,128 @@
+package gigaviewer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"mg-Downloader/pkg/cookies"
+	"mg-Downloader/pkg/httpx"
+)
+
+// EpisodeInfo 打包时写入的章节元数据
+type EpisodeInfo struct {
+	Title  string
+	Number int
+	Series string
+	Author string
+}
+
+// ComicSession 一话的章节页和页面列表，下载页面时使用同一个客户端和 cookie
+type ComicSession struct {
+	Cookies *cookies.Store
+	Client  *httpx.Client
+	URL     string
+	Doc     *goquery.Document
+	Pages   []Page
+	Info    EpisodeInfo
+}
+
+// NewComicSession 获取章节页，返回页面标题和会话。client 需要使用 jar 发送 cookie，
+// 下载过程中站点刷新的 cookie 在一话结束后保存一次（见 FinishEpisode）。
+func NewComicSession(ctx context.Context, rawURL string, jar *cookies.Store, client *httpx.Client) (string, *ComicSession, error) {
+	_, doc, err := fetchDocument(ctx, client, rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("获取章节页失败: %w", err)
+	}
+	title := doc.Find("title").Text()
+	// 需要登录的章节没有页面数据，登录 cookie 缺失或过期时按登录问题报告
+	jsonData, err := EpisodeJSON(doc)
+	if err != nil {
+		return "", nil, LoginError(jar, err)
+	}
+
+	pages, err := parsePages(jsonData)
+	if err != nil {
+		return "", nil, LoginError(jar, err)
+	}
+
+	return title, &ComicSession{
+		Cookies: jar,
+		Client:  client,
+		URL:     rawURL,
+		Doc:     doc,
+		Pages:   pages,
+		Info:    parseEpisodeInfo(jsonData, doc),
+	}, nil
+}
+
+// RenderPage 下载第 index 页（从 0 开始），返回解混淆后 PNG 编码的图片
+func (s *ComicSession) RenderPage(ctx context.Context, index int) ([]byte, error) {
+	if index < 0 || index >= len(s.Pages) {
+		return nil, fmt.Errorf("页码越界: %d", index)
+	}
+	return s.Pages[index].Render(ctx, s.Client, s.URL, index+1)
+}
+
+// Thumbnail 章节页 og:image 中的缩略图地址
+func (s *ComicSession) Thumbnail() string {
+	return s.Doc.Find(`meta[property="og:image"]`).AttrOr("content", "")
+}
+
+func parseEpisodeInfo(jsonData string, doc *goquery.Document) EpisodeInfo {
+	var data struct {
+		ReadableProduct struct {
+			Title  string  `json:"title"`
+			Number float64 `json:"number"`
+			Series struct {
+				Title string `json:"title"`
+			} `json:"series"`
+		} `json:"readableProduct"`
+	}
+	// 元数据不是必需的，解析失败时留空
+	_ = json.Unmarshal([]byte(jsonData), &data)
+
+	return EpisodeInfo{
+		Title:  data.ReadableProduct.Title,
+		Number: int(data.ReadableProduct.Number),
+		Series: data.ReadableProduct.Series.Title,
+		Author: strings.TrimSpace(doc.Find(".series-header-author").First().Text()),
+	}
+}
+
+func parsePages(jsonData string) ([]Page, error) {
+	var data struct {
+		ReadableProduct *struct {
+			PageStructure *struct {
+				Pages []struct {
+					Src    string  `json:"src"`
+					Width  float64 `json:"width"`
+					Height float64 `json:"height"`
+				} `json:"pages"`
+			} `json:"pageStructure"`
+		} `json:"readableProduct"`
+	}
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil, fmt.Errorf("解析章节数据失败: %w", err)
+	}
+	if data.ReadableProduct == nil || data.ReadableProduct.PageStructure == nil {
+		return nil, fmt.Errorf("章节数据中没有页面列表")
+	}
+
+	var pages []Page
+	for _, p := range data.ReadableProduct.PageStructure.Pages {
+		// 页面列表中还有广告等没有图片的条目
+		if p.Src != "" && p.Width > 0 && p.Height > 0 {
+			pages = append(pages, NewPage(p.Src, int(p.Width), int(p.Height)))
+		}
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Src < pages[j].Src
+	})
+	return pages, nil
+}
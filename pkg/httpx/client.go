@@ -0,0 +1,314 @@
+// Package httpx 是各站点共用的 HTTP 客户端：按状态码重试、指数退避加随机抖动、
+// 遵守 Retry-After，按主机限制请求频率和并发数，支持 HTTP/SOCKS5 代理，并以 StatusError 等类型返回错误
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultBaseDelay  = 1 * time.Second
+	defaultMaxDelay   = 30 * time.Second
+	// maxRetryAfter 服务器要求等待超过这个时间时不再重试
+	maxRetryAfter = 5 * time.Minute
+)
+
+// UserAgent 站点没有单独指定时使用的 User-Agent
+const UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+// Options 客户端参数，零值字段使用默认值
+type Options struct {
+	// Timeout 单次请求的超时时间，包括读取响应
+	Timeout time.Duration
+	// MaxRetries 第一次请求失败后最多重试的次数，小于 0 表示不重试
+	MaxRetries int
+	// BaseDelay 第一次重试前的等待时间，之后逐次翻倍
+	BaseDelay time.Duration
+	// MaxDelay 退避等待的上限
+	MaxDelay time.Duration
+	// Limit 每个主机的请求频率和并发连接数限制
+	Limit Limit
+	// Jar 保存和发送 cookie，为空时不使用 cookie
+	Jar http.CookieJar
+	// Proxy 代理地址，格式见 ValidateProxy；为空时使用环境变量
+	Proxy string
+	// Transport 为空时按 Proxy 使用共享的 Transport
+	Transport http.RoundTripper
+}
+
+// Client 带重试的 HTTP 客户端，可以被多个 goroutine 同时使用
+type Client struct {
+	http *http.Client
+	opts Options
+	// err 创建时发现的设置错误，每次请求都直接返回
+	err error
+}
+
+// New 创建客户端
+func New(opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultMaxDelay
+	}
+	c := &Client{opts: opts}
+	transport := opts.Transport
+	if transport == nil {
+		t, err := transportFor(opts.Proxy)
+		if err != nil {
+			c.err = err
+		} else {
+			transport = t
+		}
+	}
+	c.http = &http.Client{Timeout: opts.Timeout, Transport: transport, Jar: opts.Jar}
+	return c
+}
+
+// StatusError 服务器返回了非 2xx 状态码
+type StatusError struct {
+	StatusCode int
+	URL        string
+	// RetryAfter 响应中 Retry-After 要求的等待时间，没有时为 0
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("服务器返回状态码 %d: %s", e.StatusCode, e.URL)
+}
+
+// Temporary 该状态码是否值得重试
+func (e *StatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsStatus 判断 err 是否为指定状态码的 StatusError
+func IsStatus(err error, code int) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.StatusCode == code
+}
+
+// IsTimeout 判断 err 是否为请求超时（不包括调用方主动取消）
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// Do 发送请求，遇到网络错误或可重试的状态码时按退避策略重试。
+// 成功时返回 2xx 响应，由调用方关闭 Body；其他状态码返回 *StatusError。
+// 带 Body 的请求需要设置 GetBody 才能重试。
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := c.retry(req, func(r *http.Request) error {
+		var err error
+		resp, err = c.once(r)
+		return err
+	})
+	return resp, err
+}
+
+// Fetch 发送请求并读取完整的响应，读取过程中断开也会重试
+func (c *Client) Fetch(req *http.Request) ([]byte, error) {
+	var data []byte
+	err := c.retry(req, func(r *http.Request) error {
+		resp, err := c.once(r)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		data, err = io.ReadAll(resp.Body)
+		return err
+	})
+	return data, err
+}
+
+// Get 以 GET 请求 url 并读取完整的响应，header 可以为 nil
+func (c *Client) Get(ctx context.Context, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	return c.Fetch(req)
+}
+
+// HTTPClient 返回底层的 http.Client，用于不需要重试的场景
+func (c *Client) HTTPClient() *http.Client {
+	return c.http
+}
+
+// once 发送一次请求，非 2xx 响应转换为 StatusError
+func (c *Client) once(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	release := func() {}
+	if c.opts.Limit.enabled() {
+		h := limiterFor(req.URL.Hostname(), c.opts.Limit)
+		if err := h.acquire(req.Context()); err != nil {
+			return nil, err
+		}
+		release = h.release
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// 读掉剩余内容以便复用连接
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		release()
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			URL:        req.URL.String(),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+func (c *Client) retry(req *http.Request, attempt func(*http.Request) error) error {
+	ctx := req.Context()
+	var err error
+	for i := 0; ; i++ {
+		r := req
+		if i > 0 {
+			if r, err = rewind(req); err != nil {
+				return err
+			}
+		}
+
+		err = attempt(r)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if i >= c.opts.MaxRetries || !retryable(err) {
+			break
+		}
+
+		delay := c.backoff(i)
+		var se *StatusError
+		if errors.As(err, &se) && se.RetryAfter > 0 {
+			if se.RetryAfter > maxRetryAfter {
+				break
+			}
+			delay = se.RetryAfter
+		}
+		log.Printf("[HTTP] 请求失败 (%d/%d): %v，%v 后重试", i+1, c.opts.MaxRetries+1, err, delay.Round(time.Millisecond))
+		if err := Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	if c.opts.MaxRetries > 0 && retryable(err) {
+		return fmt.Errorf("已重试 %d 次: %w", c.opts.MaxRetries, err)
+	}
+	return err
+}
+
+// backoff 第 n 次重试前的等待时间：指数增长，在后一半区间内随机抖动
+func (c *Client) backoff(n int) time.Duration {
+	d := c.opts.BaseDelay << n
+	if d <= 0 || d > c.opts.MaxDelay {
+		d = c.opts.MaxDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryable 判断错误是否可能在重试后消失
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Temporary()
+	}
+	var pe *ProxyError
+	if errors.As(err, &pe) {
+		return false
+	}
+	// 其他错误都来自网络层：连接失败、超时、连接被重置或读取中断
+	return true
+}
+
+// rewind 为重试复制请求，带 Body 的请求通过 GetBody 重新获取内容
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, fmt.Errorf("请求内容无法重放，不能重试: %s", req.URL)
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// parseRetryAfter 解析秒数或 HTTP 日期形式的 Retry-After
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// Sleep 等待 d，ctx 结束时提前返回
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,207 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer 前 fail 次请求返回 status（fail 小于 0 时一直返回），之后返回 ok；
+// header 会加到失败的响应上
+func statusServer(t *testing.T, status, fail int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if fail < 0 || n <= fail {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv, calls := statusServer(t, http.StatusTooManyRequests, 1, http.Header{"Retry-After": {"1"}})
+	// 退避时间很短，等够 1 秒说明使用的是 Retry-After
+	c := New(Options{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	start := time.Now()
+	data, err := c.Get(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "ok" {
+		t.Errorf("data = %q, want ok", data)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("retried after %v, want at least 1s", elapsed)
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	srv, calls := statusServer(t, http.StatusTooManyRequests, -1, http.Header{"Retry-After": {"3600"}})
+	c := New(Options{MaxRetries: 3, BaseDelay: time.Millisecond})
+
+	_, err := c.Get(context.Background(), srv.URL, nil)
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want a 429 StatusError", err)
+	}
+	if se.RetryAfter != time.Hour {
+		t.Errorf("RetryAfter = %v, want 1h", se.RetryAfter)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestRetryServerError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		fail       int
+		maxRetries int
+		wantCalls  int32
+		wantErr    bool
+	}{
+		{"recovers", http.StatusServiceUnavailable, 2, 3, 3, false},
+		{"gives up after MaxRetries", http.StatusBadGateway, -1, 2, 3, true},
+		{"retries disabled", http.StatusServiceUnavailable, -1, -1, 1, true},
+		{"not retryable", http.StatusNotFound, -1, 3, 1, true},
+	}
+	for _, tt := range tests {
+		srv, calls := statusServer(t, tt.status, tt.fail, nil)
+		c := New(Options{MaxRetries: tt.maxRetries, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond})
+
+		_, err := c.Get(context.Background(), srv.URL, nil)
+		if n := calls.Load(); n != tt.wantCalls {
+			t.Errorf("%s: got %d requests, want %d", tt.name, n, tt.wantCalls)
+		}
+		if !tt.wantErr {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if !IsStatus(err, tt.status) {
+			t.Errorf("%s: err = %v, want status %d", tt.name, err, tt.status)
+		}
+	}
+}
+
+func TestDefaultMaxRetries(t *testing.T) {
+	srv, calls := statusServer(t, http.StatusInternalServerError, -1, nil)
+	c := New(Options{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	_, err := c.Get(context.Background(), srv.URL, nil)
+	if !strings.Contains(err.Error(), "已重试 3 次") {
+		t.Errorf("err = %v", err)
+	}
+	if n := calls.Load(); n != defaultMaxRetries+1 {
+		t.Errorf("got %d requests, want %d", n, defaultMaxRetries+1)
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// 响应之后、客户端等待重试期间取消
+		time.AfterFunc(50*time.Millisecond, cancel)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	c := New(Options{MaxRetries: 3, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	start := time.Now()
+	_, err := c.Get(ctx, srv.URL, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+	c := New(Options{MaxRetries: 1, BaseDelay: time.Millisecond})
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.Fetch(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "payload" {
+		t.Errorf("second attempt sent %q, want payload", data)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := New(Options{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+	for n, max := range []time.Duration{100, 200, 400, 800, 1000, 1000, 1000} {
+		max *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if d := c.backoff(n); d < max/2 || d > max {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", n, d, max/2, max)
+			}
+		}
+	}
+	// 移位溢出时使用上限
+	if d := c.backoff(80); d < 500*time.Millisecond || d > time.Second {
+		t.Errorf("backoff(80) = %v", d)
+	}
+}
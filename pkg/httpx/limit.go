@@ -0,0 +1,127 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Limit 对单个主机的访问限制，零值字段表示不限制。
+// 限制按主机名在整个进程内共享，同一站点的多个任务、多个客户端加起来也不会超出。
+type Limit struct {
+	// Rate 每秒最多发出的请求数
+	Rate float64
+	// MaxConns 同时进行的请求数上限，响应读完或关闭后才释放
+	MaxConns int
+}
+
+func (l Limit) enabled() bool {
+	return l.Rate > 0 || l.MaxConns > 0
+}
+
+// hostLimiter 一个主机的令牌桶和连接计数
+type hostLimiter struct {
+	mu     sync.Mutex
+	limit  Limit
+	tokens float64
+	last   time.Time
+	active int
+	// changed 有连接释放或限制变化时关闭，唤醒等待的请求
+	changed chan struct{}
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[string]*hostLimiter)
+)
+
+// limiterFor 返回 host 的限制器，并更新为最新的限制
+func limiterFor(host string, l Limit) *hostLimiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	h, ok := limiters[host]
+	if !ok {
+		h = &hostLimiter{limit: l, tokens: 1, last: time.Now(), changed: make(chan struct{})}
+		limiters[host] = h
+		return h
+	}
+	h.mu.Lock()
+	if h.limit != l {
+		h.limit = l
+		h.notify()
+	}
+	h.mu.Unlock()
+	return h
+}
+
+// acquire 等待连接名额和令牌，成功后必须调用 release
+func (h *hostLimiter) acquire(ctx context.Context) error {
+	for {
+		h.mu.Lock()
+		if h.limit.MaxConns <= 0 || h.active < h.limit.MaxConns {
+			h.active++
+			h.mu.Unlock()
+			break
+		}
+		wait := h.changed
+		h.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wait:
+		}
+	}
+
+	if err := Sleep(ctx, h.reserve()); err != nil {
+		h.release()
+		return err
+	}
+	return nil
+}
+
+// reserve 取出一个令牌，返回需要等待的时间；令牌不足时预支，后来的请求依次排在后面
+func (h *hostLimiter) reserve() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.limit.Rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	h.tokens += now.Sub(h.last).Seconds() * h.limit.Rate
+	if h.tokens > 1 {
+		h.tokens = 1
+	}
+	h.last = now
+	h.tokens--
+	if h.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-h.tokens / h.limit.Rate * float64(time.Second))
+}
+
+func (h *hostLimiter) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.active--
+	h.notify()
+}
+
+// notify 唤醒所有等待者，调用时需持有 mu
+func (h *hostLimiter) notify() {
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+// releaseBody 关闭响应时释放连接名额
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
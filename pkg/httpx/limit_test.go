@@ -0,0 +1,85 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitMaxConns(t *testing.T) {
+	h := limiterFor("maxconns.test", Limit{MaxConns: 2})
+	var active, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h.acquire(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			active.Add(-1)
+			h.release()
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak concurrency = %d, want 2", p)
+	}
+}
+
+func TestLimitRate(t *testing.T) {
+	h := limiterFor("rate.test", Limit{Rate: 20})
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := h.acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		h.release()
+	}
+	// 第一个令牌立即可用，之后每 50ms 一个
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("5 requests at 20/s took %v, want at least 200ms", elapsed)
+	}
+}
+
+func TestLimitCancelWhileWaiting(t *testing.T) {
+	h := limiterFor("cancel.test", Limit{MaxConns: 1})
+	if err := h.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer h.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.active != 1 {
+		t.Errorf("active = %d after a cancelled acquire, want 1", h.active)
+	}
+}
+
+func TestLimitShared(t *testing.T) {
+	a := limiterFor("shared.test", Limit{MaxConns: 1})
+	b := limiterFor("shared.test", Limit{MaxConns: 3})
+	if a != b {
+		t.Fatal("clients of the same host got different limiters")
+	}
+	if a.limit.MaxConns != 3 {
+		t.Errorf("MaxConns = %d, want the latest limit 3", a.limit.MaxConns)
+	}
+}
@@ -0,0 +1,14 @@
+// Package ourfeel registers ourfeel.jp, a GigaViewer site run by Kodansha.
+package ourfeel
+
+import "mg-Downloader/pkg/gigaviewer"
+
+func init() {
+	gigaviewer.Register(gigaviewer.Site{
+		Name:       "ourfeel",
+		BaseURL:    "https://ourfeel.jp",
+		Host:       "ourfeel.jp",
+		CookieFile: "./cookies/cookie.of.json",
+		Publisher:  "講談社",
+	})
+}
@@ -0,0 +1,250 @@
+// Package pathtmpl 根据模板生成输出路径，例如
+//
+//	{series}/{episode_number:03} {episode_title}/{page:03}.{ext}
+//
+// 最后一段是每页的文件名，必须包含 {page}；前面各段是一话所在的文件夹。
+// 所有站点共用同一套字段，变量中的非法字符会被替换，保证在 Windows 和 Linux 上都能使用。
+package pathtmpl
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Default 默认模板：页面直接保存在输出目录中
+const Default = "{page:03}.{ext}"
+
+// maxSegment 单个文件名的最大字节数，日文一个字符占 3 字节，留出余量给临时文件后缀
+const maxSegment = 200
+
+// Vars 模板可以使用的字段
+type Vars struct {
+	Site          string // {site}
+	Series        string // {series}
+	Title         string // {title} 页面标题
+	EpisodeTitle  string // {episode_title}
+	EpisodeID     string // {episode_id}
+	EpisodeNumber int    // {episode_number}
+	Author        string // {author}
+	Page          int    // {page} 从 1 开始
+	Ext           string // {ext}
+}
+
+// field 模板中的一个字段或一段文字
+type field struct {
+	literal string
+	name    string
+	width   int
+}
+
+// Template 解析后的模板
+type Template struct {
+	raw  string
+	dirs [][]field
+	file []field
+}
+
+// Parse 解析模板，空字符串使用 Default
+func Parse(s string) (*Template, error) {
+	if strings.TrimSpace(s) == "" {
+		s = Default
+	}
+	if filepath.IsAbs(s) || strings.HasPrefix(s, "/") || strings.HasPrefix(s, `\`) {
+		return nil, fmt.Errorf("路径模板必须是相对路径: %s", s)
+	}
+
+	segments := strings.Split(strings.ReplaceAll(s, `\`, "/"), "/")
+	t := &Template{raw: s}
+	for i, seg := range segments {
+		if seg == "" || seg == "." || seg == ".." {
+			return nil, fmt.Errorf("路径模板中有无效的路径段: %s", s)
+		}
+		fields, err := parseSegment(seg)
+		if err != nil {
+			return nil, err
+		}
+		last := i == len(segments)-1
+		for _, f := range fields {
+			if !last && (f.name == "page" || f.name == "ext") {
+				return nil, fmt.Errorf("{%s} 只能用在文件名中: %s", f.name, s)
+			}
+		}
+		if last {
+			t.file = fields
+		} else {
+			t.dirs = append(t.dirs, fields)
+		}
+	}
+
+	hasPage := false
+	for _, f := range t.file {
+		hasPage = hasPage || f.name == "page"
+	}
+	if !hasPage {
+		return nil, fmt.Errorf("路径模板的文件名中必须包含 {page}: %s", s)
+	}
+	return t, nil
+}
+
+// String 返回原始模板
+func (t *Template) String() string {
+	return t.raw
+}
+
+// HasDir 模板是否为每话指定了文件夹
+func (t *Template) HasDir() bool {
+	return len(t.dirs) > 0
+}
+
+// Dir 生成一话所在的相对文件夹路径，模板没有文件夹时返回空字符串
+func (t *Template) Dir(v Vars) string {
+	parts := make([]string, len(t.dirs))
+	for i, seg := range t.dirs {
+		parts[i] = segmentName(render(seg, v), false)
+	}
+	return filepath.Join(parts...)
+}
+
+// File 生成一页的文件名
+func (t *Template) File(v Vars) string {
+	return segmentName(render(t.file, v), true)
+}
+
+func parseSegment(seg string) ([]field, error) {
+	var fields []field
+	for seg != "" {
+		open := strings.IndexByte(seg, '{')
+		if open < 0 {
+			fields = append(fields, field{literal: seg})
+			break
+		}
+		if open > 0 {
+			fields = append(fields, field{literal: seg[:open]})
+		}
+		end := strings.IndexByte(seg[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("路径模板缺少 }: %s", seg)
+		}
+		f, err := parseField(seg[open+1 : open+end])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+		seg = seg[open+end+1:]
+	}
+	return fields, nil
+}
+
+// parseField 解析 "name" 或 "name:03"，宽度只对数字字段有效
+func parseField(s string) (field, error) {
+	name, spec, hasSpec := strings.Cut(s, ":")
+	f := field{name: name}
+	switch name {
+	case "page", "episode_number":
+		if hasSpec {
+			width, err := strconv.Atoi(spec)
+			if err != nil || width < 0 || width > 9 {
+				return field{}, fmt.Errorf("无效的宽度: {%s}", s)
+			}
+			f.width = width
+		}
+	case "site", "series", "title", "episode_title", "episode_id", "author", "ext":
+		if hasSpec {
+			return field{}, fmt.Errorf("{%s} 不支持宽度", name)
+		}
+	default:
+		return field{}, fmt.Errorf("未知的字段: {%s}", name)
+	}
+	return f, nil
+}
+
+func render(fields []field, v Vars) string {
+	var b strings.Builder
+	for _, f := range fields {
+		if f.name == "" {
+			b.WriteString(f.literal)
+			continue
+		}
+		b.WriteString(Sanitize(value(f, v)))
+	}
+	return b.String()
+}
+
+func value(f field, v Vars) string {
+	switch f.name {
+	case "page":
+		return fmt.Sprintf("%0*d", f.width, v.Page)
+	case "episode_number":
+		return fmt.Sprintf("%0*d", f.width, v.EpisodeNumber)
+	case "site":
+		return v.Site
+	case "series":
+		return v.Series
+	case "title":
+		return v.Title
+	case "episode_title":
+		return v.EpisodeTitle
+	case "episode_id":
+		return v.EpisodeID
+	case "author":
+		return v.Author
+	case "ext":
+		return v.Ext
+	}
+	return ""
+}
+
+// Sanitize 替换 Windows 和 Linux 文件名中不允许的字符，去掉首尾空白和结尾的点
+func Sanitize(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	return strings.TrimRight(strings.TrimSpace(name), ". ")
+}
+
+// segmentName 整理渲染后的一段路径：处理空名、Windows 保留名和过长的名字
+func segmentName(name string, isFile bool) string {
+	name = Sanitize(name)
+	if name == "" {
+		name = "_"
+	}
+
+	stem, ext := name, ""
+	if isFile {
+		if i := strings.LastIndexByte(name, '.'); i > 0 && len(name)-i <= 10 {
+			stem, ext = name[:i], name[i:]
+		}
+	}
+	// 保留名带扩展名也不行，在第一个点之前加 _
+	if base, _, _ := strings.Cut(stem, "."); reserved[strings.ToUpper(base)] {
+		stem = base + "_" + stem[len(base):]
+	}
+	if len(stem)+len(ext) > maxSegment {
+		stem = truncate(stem, maxSegment-len(ext))
+	}
+	return stem + ext
+}
+
+// truncate 按字节截断但不截断多字节字符
+func truncate(s string, n int) string {
+	for len(s) > n {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	return strings.TrimRight(s, ". ")
+}
+
+// reserved Windows 的保留设备名，不区分大小写，带扩展名也不行
+var reserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
@@ -0,0 +1,156 @@
+package pathtmpl
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var testVars = Vars{
+	Site:          "comicDays",
+	Series:        "作品名",
+	Title:         "第3話 はじまり - 作品名",
+	EpisodeTitle:  "第3話 はじまり",
+	EpisodeID:     "3269754496",
+	EpisodeNumber: 3,
+	Author:        "作者",
+	Page:          7,
+	Ext:           "png",
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		dir  string
+		file string
+	}{
+		{"", "", "007.png"},
+		{"{page}.{ext}", "", "7.png"},
+		{"{series}/{episode_number:03} {episode_title}/{page:03}.{ext}",
+			filepath.Join("作品名", "003 第3話 はじまり"), "007.png"},
+		{`{site}\{episode_id}\p{page:2}.{ext}`, filepath.Join("comicDays", "3269754496"), "p07.png"},
+		{"{author}/{title}/{page:0}.{ext}", filepath.Join("作者", "第3話 はじまり - 作品名"), "7.png"},
+		{"{series}-{page:04}", "", "作品名-0007"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse(tt.tmpl)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.tmpl, err)
+			continue
+		}
+		if got := tmpl.Dir(testVars); got != tt.dir {
+			t.Errorf("%q: Dir = %q, want %q", tt.tmpl, got, tt.dir)
+		}
+		if got := tmpl.HasDir(); got != (tt.dir != "") {
+			t.Errorf("%q: HasDir = %v", tt.tmpl, got)
+		}
+		if got := tmpl.File(testVars); got != tt.file {
+			t.Errorf("%q: File = %q, want %q", tt.tmpl, got, tt.file)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, tmpl := range []string{
+		"{unknown}/{page}.{ext}",
+		"{Series}/{page}.{ext}",
+		"{series:3}/{page}.{ext}",
+		"{page:x}.{ext}",
+		"{page:-1}.{ext}",
+		"{page:10}.{ext}",
+		"{series/{page}.{ext}",
+		"{series}.{ext}",
+		"{page}/{series}.{ext}",
+		"{ext}/{page}",
+		"../{page}.{ext}",
+		"{series}/../{page}.{ext}",
+		"./{page}.{ext}",
+		"{series}//{page}.{ext}",
+		"/abs/{page}.{ext}",
+		`\abs\{page}.{ext}`,
+		"{series}/",
+	} {
+		if _, err := Parse(tmpl); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", tmpl)
+		}
+	}
+}
+
+func TestIllegalCharacters(t *testing.T) {
+	tmpl, err := Parse("{series}/{episode_title}/{page:03}.{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		series string
+		title  string
+		dir    string
+	}{
+		{`a<b>c:d"e`, `f|g?h*i`, filepath.Join("a_b_c_d_e", "f_g_h_i")},
+		// 变量中的路径分隔符不会生成新的文件夹
+		{"a/b", `c\d`, filepath.Join("a_b", "c_d")},
+		{"tab\there", "nl\nhere\x7f", filepath.Join("tab_here", "nl_here_")},
+		// 变量不能跳出输出目录
+		{"..", "../..", filepath.Join("_", ".._")},
+		{"  作品.  ", "...", filepath.Join("作品", "_")},
+		{"", "", filepath.Join("_", "_")},
+		// Windows 保留设备名
+		{"CON", "aux.txt", filepath.Join("CON_", "aux_.txt")},
+		{"nul.tar.gz", "LPT1", filepath.Join("nul_.tar.gz", "LPT1_")},
+		{"console", "com1", filepath.Join("console", "com1_")},
+	}
+	for _, tt := range tests {
+		v := testVars
+		v.Series, v.EpisodeTitle = tt.series, tt.title
+		if got := tmpl.Dir(v); got != tt.dir {
+			t.Errorf("Dir(%q, %q) = %q, want %q", tt.series, tt.title, got, tt.dir)
+		}
+	}
+}
+
+func TestLongNames(t *testing.T) {
+	tmpl, err := Parse("{series}/{episode_title}_{page:03}.{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := testVars
+	v.Series = strings.Repeat("長", 100)
+	v.EpisodeTitle = strings.Repeat("a", 300)
+
+	dir := tmpl.Dir(v)
+	if len(dir) > maxSegment || !utf8.ValidString(dir) || !strings.HasPrefix(v.Series, dir) {
+		t.Errorf("Dir = %q (%d bytes)", dir, len(dir))
+	}
+
+	// 截断时保留扩展名
+	file := tmpl.File(v)
+	if len(file) != maxSegment || !strings.HasSuffix(file, ".png") {
+		t.Errorf("File = %q (%d bytes)", file, len(file))
+	}
+
+	// 截断后结尾的点和空格会被去掉
+	v.EpisodeTitle = strings.Repeat("a", maxSegment-12) + " . . . . . . . ."
+	if file := tmpl.File(v); strings.HasSuffix(strings.TrimSuffix(file, ".png"), ".") {
+		t.Errorf("File = %q", file)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"ok":        "ok",
+		" padded ":  "padded",
+		"trailing.": "trailing",
+		"a/b\\c":    "a_b_c",
+		"第1話：前編":    "第1話：前編",
+		"what?":     "what_",
+		"dots. . .": "dots",
+		".hidden":   ".hidden",
+		"\x00\x1f":  "__",
+	}
+	for in, want := range tests {
+		if got := Sanitize(in); got != want {
+			t.Errorf("Sanitize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,56 +1,37 @@
 package pocketShonenmagazine
 
 import (
+	"context"
 	"crypto/sha256"
 	"crypto/sha512"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"image"
 	"image/jpeg"
-	"io"
 	"net/http"
-	"os"
+	"net/url"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
-)
 
-// DownloadConfig 下载配置
-type DownloadConfig struct {
-	URL          string
-	OutputDir    string
-	ScrambleSeed int
-	TileCount    int
-	Timeout      time.Duration
-	Client       *http.Client
-}
+	"mg-Downloader/pkg/httpx"
+)
 
-type Cookie struct {
-	Domain         string  `json:"domain"`
-	ExpirationDate float64 `json:"expirationDate"`
-	HostOnly       bool    `json:"hostOnly"`
-	HTTPOnly       bool    `json:"httpOnly"`
-	Name           string  `json:"name"`
-	Path           string  `json:"path"`
-	SameSite       string  `json:"sameSite"`
-	Secure         bool    `json:"secure"`
-	Session        bool    `json:"session"`
-	StoreID        string  `json:"storeId"`
-	Value          string  `json:"value"`
-}
+const (
+	apiBase = "https://api.pocket.shonenmagazine.com"
+	// apiHashSeed 计算 x-manga-hash 签名时使用的种子
+	apiHashSeed = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855_cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"
+)
 
 // EpisodeData Shonen Magazine API 响应结构
 type ShonenMagazineEpisodeData struct {
-	ScrambleSeed int      `json:"scramble_seed"`
+	// ScrambleSeed 图片混淆的种子，接口没有返回时为 nil
+	ScrambleSeed *int     `json:"scramble_seed"`
 	PageList     []string `json:"page_list"`
 }
 
-var EpisodeData *ShonenMagazineEpisodeData
-var Cookies *[]Cookie
-
 // Xorshift32 随机数生成器
 type Xorshift32 struct {
 	state uint32
@@ -182,12 +163,12 @@ func UnscrambleImage(img image.Image, scrambleSeed, tileCount int) (image.Image,
 }
 
 // DownloadImage 下载单个图片
-func DownloadImage(url string, client *http.Client, timeout time.Duration) ([]byte, error) {
+func DownloadImage(ctx context.Context, url string, client *httpx.Client, timeout time.Duration) ([]byte, error) {
 	if client == nil {
-		client = &http.Client{Timeout: timeout}
+		client = httpx.New(httpx.Options{Timeout: timeout})
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -198,17 +179,11 @@ func DownloadImage(url string, client *http.Client, timeout time.Duration) ([]by
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
 	req.Header.Set("Referer", "https://pocket.shonenmagazine.com/")
 
-	resp, err := client.Do(req)
+	data, err := client.Fetch(req)
 	if err != nil {
 		return nil, fmt.Errorf("下载图片失败: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("服务器返回非200状态码: %d", resp.StatusCode)
-	}
-
-	return io.ReadAll(resp.Body)
+	return data, nil
 }
 
 // ProcessImage 处理图片（解扰）
@@ -235,20 +210,6 @@ func ProcessImage(imgData []byte, scrambleSeed int, tileCount int) ([]byte, erro
 	return []byte(buf.String()), nil
 }
 
-// SaveImage 保存图片到文件
-func SaveImage(data []byte, filepath string) error {
-	// 创建目录
-	dir := filepath[:len(filepath)-len(filepath[strings.LastIndex(filepath, "/")+1:])]
-	if dir != "" {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("创建目录失败: %w", err)
-		}
-	}
-
-	// 写入文件
-	return os.WriteFile(filepath, data, 0644)
-}
-
 // ComputeHash 计算参数哈希
 func ComputeHash(params map[string]string, seed string) (string, error) {
 	// 获取并排序所有键
@@ -285,26 +246,6 @@ func ComputeHash(params map[string]string, seed string) (string, error) {
 	return hex.EncodeToString(finalHash[:]), nil
 }
 
-func Load() ([]Cookie, error) {
-	file, err := os.Open("./cookies/cookie.ps.json")
-	if err != nil {
-		return nil, fmt.Errorf("could not open cookie file: %v", err)
-	}
-	defer file.Close()
-
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("could not read cookie file: %v", err)
-	}
-
-	var cookies []Cookie
-	if err := json.Unmarshal(bytes, &cookies); err != nil {
-		return nil, fmt.Errorf("could not parse cookie file: %v", err)
-	}
-
-	return cookies, nil
-}
-
 // 辅助函数
 func extractEpisodeID(url string) string {
 	regex := regexp.MustCompile(`episode/(\d+)`)
@@ -322,168 +263,57 @@ func min(a, b int) int {
 	return b
 }
 
-var UrlString string
-
-func GetFirstPageFromPocketShonenmagazine(urlstr string) (string, string, error) {
+// FetchEpisode 获取章节标题、缩略图地址和图片列表，cookie 由 client 携带
+func FetchEpisode(ctx context.Context, client *httpx.Client, urlstr string) (string, string, *ShonenMagazineEpisodeData, error) {
 	// 提取episode ID
 	episodeID := extractEpisodeID(urlstr)
 	if episodeID == "" {
-		return "", "", fmt.Errorf("无效的URL: 无法提取episode ID")
-	}
-
-	client := &http.Client{
-		Timeout: 30 * time.Second, // 设置超时时间
+		return "", "", nil, fmt.Errorf("无效的URL: 无法提取episode ID")
 	}
 
 	// 首先访问网页获取标题
-	pageReq, err := http.NewRequest("GET", urlstr, nil)
+	pageReq, err := http.NewRequestWithContext(ctx, "GET", urlstr, nil)
 	if err != nil {
-		return "", "", fmt.Errorf("创建页面请求失败: %w", err)
+		return "", "", nil, fmt.Errorf("创建页面请求失败: %w", err)
 	}
 
 	pageReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36")
 	pageReq.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
 	pageReq.Header.Set("Accept-Language", "en-US,en;q=0.9")
 
-	pageResp, err := client.Do(pageReq)
+	htmlData, err := client.Fetch(pageReq)
 	if err != nil {
-		return "", "", fmt.Errorf("请求页面失败: %w", err)
-	}
-	defer pageResp.Body.Close()
-
-	if pageResp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("页面请求失败，状态码: %d", pageResp.StatusCode)
-	}
-
-	// 读取HTML内容
-	htmlData, err := io.ReadAll(pageResp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("读取页面内容失败: %w", err)
+		return "", "", nil, fmt.Errorf("请求页面失败: %w", err)
 	}
 
 	// 解析HTML获取标题
 	title := extractTitleFromHTML(string(htmlData))
 	if title == "" {
-		return "", "", fmt.Errorf("无法从HTML提取标题")
+		return "", "", nil, fmt.Errorf("无法从HTML提取标题")
 	}
 
-	// 构建API URL
-	apiURL := fmt.Sprintf("https://api.pocket.shonenmagazine.com/web/episode/viewer?episode_id=%s", episodeID)
-
-	// 计算API签名
-	params := map[string]string{
-		"episode_id": episodeID,
-	}
-	seed := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855_cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"
-	hash1, err := ComputeHash(params, seed)
-	if err != nil {
-		return "", "", fmt.Errorf("计算API签名失败: %w", err)
-	}
-
-	// 创建API请求
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return "", "", fmt.Errorf("创建API请求失败: %w", err)
-	}
+	thumbnail := extractThumbnailFromHTML(string(htmlData))
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36")
-	req.Header.Set("x-manga-is-crawler", "false")
-	req.Header.Set("x-manga-platform", "3")
-	req.Header.Set("x-manga-hash", hash1)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Referer", "https://pocket.shonenmagazine.com/")
-	cookies, err := Load()
-	if err != nil {
-		return "", "", err
-	}
-	//Cookies = &cookies
-	for _, cookie := range cookies {
-		req.AddCookie(&http.Cookie{
-			Name:  cookie.Name,
-			Value: cookie.Value,
-		})
-	}
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", "", fmt.Errorf("请求API失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("API请求失败，状态码: %d", resp.StatusCode)
-	}
-
-	// 解析响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("读取API响应失败: %w", err)
-	}
-
-	// 解析JSON响应到结构体
+	// 获取图片列表
 	var episodeData ShonenMagazineEpisodeData
-	if err := json.Unmarshal(body, &episodeData); err != nil {
-		return "", "", fmt.Errorf("解析API响应JSON失败: %w", err)
-	}
-
-	// 保存到全局变量
-	EpisodeData = &episodeData
-
-	// 获取第一页图片URL
-	var firstPageURL string
-	if len(episodeData.PageList) > 0 {
-		firstPageURL = episodeData.PageList[0]
-	}
-
-	if firstPageURL == "" {
-		return "", "", fmt.Errorf("无法获取第一页图片URL")
-	}
-
-	// 下载第一页图片
-	imgReq, err := http.NewRequest("GET", firstPageURL, nil)
-	if err != nil {
-		return title, "", fmt.Errorf("创建图片请求失败: %w", err)
+	if err := fetchAPI(ctx, client, "/web/episode/viewer", map[string]string{"episode_id": episodeID}, &episodeData); err != nil {
+		return "", "", nil, err
 	}
-
-	imgReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	imgReq.Header.Set("Accept", "image/webp,image/apng,image/*,*/*;q=0.8")
-	imgReq.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	imgReq.Header.Set("Referer", "https://pocket.shonenmagazine.com/")
-
-	imgResp, err := client.Do(imgReq)
-	if err != nil {
-		return title, "", fmt.Errorf("下载图片失败: %w", err)
-	}
-	defer imgResp.Body.Close()
-
-	if imgResp.StatusCode != http.StatusOK {
-		return title, "", fmt.Errorf("图片下载失败，状态码: %d", imgResp.StatusCode)
+	// 图片都经过混淆，没有种子时无法还原，直接报错而不是保存混淆的图片
+	if episodeData.ScrambleSeed == nil {
+		return "", "", nil, fmt.Errorf("章节数据中没有 scramble_seed，无法还原图片")
 	}
 
-	// 读取图片数据
-	imgData, err := io.ReadAll(imgResp.Body)
-	if err != nil {
-		return title, "", fmt.Errorf("读取图片数据失败: %w", err)
-	}
+	return title, thumbnail, &episodeData, nil
+}
 
-	// 处理图片（如果需要解扰）
-	var processedImgData []byte
-	if episodeData.ScrambleSeed > 0 {
-		processedImgData, err = ProcessImage(imgData, episodeData.ScrambleSeed, 4) // tileCount默认为4
-		if err != nil {
-			return title, "", fmt.Errorf("处理图片失败: %w", err)
-		}
-	} else {
-		processedImgData = imgData
+// extractThumbnailFromHTML 从HTML的 og:image 中提取缩略图地址
+func extractThumbnailFromHTML(html string) string {
+	re := regexp.MustCompile(`<meta[^>]+property="og:image"[^>]+content="([^"]*)"`)
+	if matches := re.FindStringSubmatch(html); len(matches) > 1 {
+		return strings.ReplaceAll(matches[1], "&amp;", "&")
 	}
-
-	// 转换为base64
-	base64Str := base64.StdEncoding.EncodeToString(processedImgData)
-
-	// 添加data:image/jpeg;base64,前缀
-	fullBase64 := "data:image/jpeg;base64," + base64Str
-
-	return title, fullBase64, nil
+	return ""
 }
 
 // extractTitleFromHTML 从HTML中提取标题
@@ -527,10 +357,42 @@ func extractTitleFromHTML(html string) string {
 	return ""
 }
 
-//func DownloadMangaFromShonenmagazine(outDir string) error {
-//	err := DownloadMangaImages(UrlString, outDir)
-//	if err != nil {
-//		return err
-//	}
-//	return nil
-//}
+// fetchAPI 带签名请求 API，并将 JSON 响应解析到 out
+func fetchAPI(ctx context.Context, client *httpx.Client, endpoint string, params map[string]string, out any) error {
+	// 计算API签名
+	hash, err := ComputeHash(params, apiHashSeed)
+	if err != nil {
+		return fmt.Errorf("计算API签名失败: %w", err)
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	apiURL := apiBase + endpoint + "?" + query.Encode()
+
+	// 创建API请求
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建API请求失败: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36")
+	req.Header.Set("x-manga-is-crawler", "false")
+	req.Header.Set("x-manga-platform", "3")
+	req.Header.Set("x-manga-hash", hash)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Referer", "https://pocket.shonenmagazine.com/")
+
+	// 发送请求
+	body, err := client.Fetch(req)
+	if err != nil {
+		return fmt.Errorf("请求API失败: %w", err)
+	}
+
+	// 解析响应
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析API响应JSON失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package pocketShonenmagazine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"mg-Downloader/pkg/cookies"
+	"mg-Downloader/pkg/httpx"
+	"mg-Downloader/pkg/provider"
+)
+
+// defaultSettings 用户没有设置的参数使用这些默认值
+var defaultSettings = provider.Settings{
+	Timeout:    30 * time.Second,
+	MaxRetries: 3,
+	RetryDelay: 1 * time.Second,
+	RateLimit:  2,
+	MaxConns:   4,
+	TileCount:  4,
+	CookieFile: "./cookies/cookie.ps.json",
+}
+
+type siteProvider struct {
+	mu       sync.RWMutex
+	settings provider.Settings
+}
+
+func init() {
+	provider.Register(&siteProvider{settings: defaultSettings})
+}
+
+// DefaultSettings 返回站点的默认参数
+func (*siteProvider) DefaultSettings() provider.Settings {
+	return defaultSettings
+}
+
+// Settings 返回当前生效的参数
+func (p *siteProvider) Settings() provider.Settings {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.settings
+}
+
+// Configure 应用参数，未设置的字段使用默认值
+func (p *siteProvider) Configure(s provider.Settings) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.settings = s.Or(defaultSettings)
+}
+
+func (*siteProvider) Name() string {
+	return "PocketShonenmagazine"
+}
+
+// CookieDomains 导入 cookie 时只保留这些域名下的 cookie
+func (*siteProvider) CookieDomains() []string {
+	return []string{"shonenmagazine.com"}
+}
+
+// CheckLogin 检查 cookie 文件中是否有未过期的 cookie。
+// 站点没有可以确认登录用户的公开接口，只检查本地文件，cookie 是否被站点接受要到下载时才知道。
+func (p *siteProvider) CheckLogin(ctx context.Context) (*provider.LoginStatus, error) {
+	jar, err := cookies.Shared(p.Settings().CookieFile)
+	if err != nil {
+		return nil, err
+	}
+	status := &provider.LoginStatus{}
+	session, err := jar.Session()
+	if err != nil {
+		return status, err
+	}
+	status.LoggedIn = true
+	status.Expires = session.Expires()
+	return status, nil
+}
+
+func (*siteProvider) Match(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Hostname() == "pocket.shonenmagazine.com" && strings.Contains(u.Path, "/episode/")
+}
+
+func (p *siteProvider) FetchEpisode(ctx context.Context, rawURL string) (*provider.Episode, error) {
+	settings := p.Settings()
+	jar, err := cookies.Shared(settings.CookieFile)
+	if err != nil {
+		return nil, err
+	}
+	defer saveCookies(jar)
+	client := newClient(settings, jar)
+	title, thumbnail, episodeData, err := FetchEpisode(ctx, client, rawURL)
+	if err != nil {
+		return nil, loginError(jar, err)
+	}
+	episodeID := extractEpisodeID(rawURL)
+	info := fetchEpisodeInfo(ctx, client, episodeID)
+
+	pages := make([]provider.Page, len(episodeData.PageList))
+	for i, src := range episodeData.PageList {
+		pages[i] = provider.Page{Index: i, Src: src}
+	}
+
+	return &provider.Episode{
+		ID:    episodeID,
+		URL:   rawURL,
+		Title: title,
+		Pages: pages,
+		Data:  episodeData,
+
+		Thumbnail: thumbnail,
+
+		Series:       info.Series,
+		EpisodeTitle: info.Title,
+		Number:       info.Number,
+		Author:       info.Author,
+		Publisher:    "講談社",
+	}, nil
+}
+
+func (p *siteProvider) FetchPage(ctx context.Context, ep *provider.Episode, index int) (*provider.Image, error) {
+	episodeData, ok := ep.Data.(*ShonenMagazineEpisodeData)
+	if !ok {
+		return nil, fmt.Errorf("章节数据不属于PocketShonenmagazine")
+	}
+	if index < 0 || index >= len(episodeData.PageList) {
+		return nil, fmt.Errorf("页码越界: %d", index)
+	}
+
+	// 图片地址自带签名，不需要 cookie
+	settings := p.Settings()
+	imgData, err := DownloadImage(ctx, episodeData.PageList[index], newClient(settings, nil), settings.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if episodeData.ScrambleSeed == nil {
+		return nil, fmt.Errorf("章节数据中没有 scramble_seed，无法还原图片")
+	}
+	imgData, err = ProcessImage(imgData, *episodeData.ScrambleSeed, settings.TileCount)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.Image{Data: imgData, Ext: "jpg"}, nil
+}
+
+// newClient 按网络设置创建 HTTP 客户端，jar 为 nil 时不携带 cookie
+func newClient(s provider.Settings, jar *cookies.Store) *httpx.Client {
+	opts := httpx.Options{
+		Timeout:    s.Timeout,
+		MaxRetries: s.MaxRetries,
+		BaseDelay:  s.RetryDelay,
+		Limit:      httpx.Limit{Rate: s.RateLimit, MaxConns: s.MaxConns},
+		Proxy:      s.Proxy,
+	}
+	if jar != nil {
+		opts.Jar = jar
+	}
+	return httpx.New(opts)
+}
+
+// loginError API 拒绝访问时说明是登录问题：没有 cookie 时为未登录，有 cookie 时为 cookie 已失效
+func loginError(jar *cookies.Store, err error) error {
+	if !httpx.IsStatus(err, http.StatusUnauthorized) && !httpx.IsStatus(err, http.StatusForbidden) {
+		return err
+	}
+	if _, serr := jar.Session(); serr != nil {
+		return fmt.Errorf("%w (%v)", serr, err)
+	}
+	return fmt.Errorf("%w: 站点拒绝了保存的 cookie (%v)", provider.ErrCookieExpired, err)
+}
+
+// saveCookies 将站点更新的 cookie 写回文件
+func saveCookies(jar *cookies.Store) {
+	if err := jar.Save(); err != nil {
+		log.Printf("[PocketShonenmagazine] 保存 cookie 失败: %v", err)
+	}
+}
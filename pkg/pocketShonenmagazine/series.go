@@ -0,0 +1,174 @@
+package pocketShonenmagazine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mg-Downloader/pkg/cookies"
+	"mg-Downloader/pkg/httpx"
+	"mg-Downloader/pkg/provider"
+)
+
+// episodeListBatch 每次请求章节详情时携带的章节数
+const episodeListBatch = 100
+
+// titleDetail /web/title/detail 的响应
+type titleDetail struct {
+	WebTitle struct {
+		TitleID       int    `json:"title_id"`
+		TitleName     string `json:"title_name"`
+		AuthorText    string `json:"author_text"`
+		EpisodeIDList []int  `json:"episode_id_list"`
+	} `json:"web_title"`
+}
+
+// episodeList /web/episode/list 的响应
+type episodeList struct {
+	EpisodeList []struct {
+		EpisodeID   int    `json:"episode_id"`
+		TitleID     int    `json:"title_id"`
+		EpisodeName string `json:"episode_name"`
+		Index       int    `json:"index"`
+		StartTime   string `json:"start_time"`
+		// Point 阅读所需的点数，0 表示免费
+		Point int `json:"point"`
+	} `json:"episode_list"`
+}
+
+func (*siteProvider) MatchSeries(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Hostname() == "pocket.shonenmagazine.com" && strings.Contains(u.Path, "/title/")
+}
+
+// FetchSeries 通过作品页链接获取作品信息和全部章节
+func (p *siteProvider) FetchSeries(ctx context.Context, rawURL string) (*provider.Series, error) {
+	titleID := extractTitleID(rawURL)
+	if titleID == "" {
+		return nil, fmt.Errorf("无效的URL: 无法提取title ID")
+	}
+
+	settings := p.Settings()
+	jar, err := cookies.Shared(settings.CookieFile)
+	if err != nil {
+		return nil, err
+	}
+	defer saveCookies(jar)
+	client := newClient(settings, jar)
+
+	var detail titleDetail
+	if err := fetchAPI(ctx, client, "/web/title/detail", map[string]string{"title_id": titleID}, &detail); err != nil {
+		return nil, err
+	}
+
+	ids := detail.WebTitle.EpisodeIDList
+	var episodes []provider.SeriesEpisode
+	for start := 0; start < len(ids); start += episodeListBatch {
+		batch := make([]string, 0, episodeListBatch)
+		for _, id := range ids[start:min(start+episodeListBatch, len(ids))] {
+			batch = append(batch, strconv.Itoa(id))
+		}
+
+		var list episodeList
+		params := map[string]string{"episode_id_list": strings.Join(batch, ",")}
+		if err := fetchAPI(ctx, client, "/web/episode/list", params, &list); err != nil {
+			return nil, err
+		}
+		for _, e := range list.EpisodeList {
+			id := strconv.Itoa(e.EpisodeID)
+			episodes = append(episodes, provider.SeriesEpisode{
+				ID:          id,
+				URL:         "https://pocket.shonenmagazine.com/episode/" + id,
+				Number:      e.Index,
+				Title:       e.EpisodeName,
+				PublishedAt: parseStartTime(e.StartTime),
+				Free:        e.Point == 0,
+			})
+		}
+	}
+
+	// 接口按发布顺序返回，没有话数时按顺序编号
+	for i := range episodes {
+		if episodes[i].Number == 0 {
+			episodes[i].Number = i + 1
+		}
+	}
+	// 选择章节时假定从旧到新排列，接口的顺序不一定如此
+	sort.SliceStable(episodes, func(i, j int) bool {
+		return episodes[i].Number < episodes[j].Number
+	})
+
+	return &provider.Series{
+		ID:       titleID,
+		URL:      rawURL,
+		Title:    detail.WebTitle.TitleName,
+		Author:   detail.WebTitle.AuthorText,
+		Episodes: episodes,
+	}, nil
+}
+
+// episodeInfo 打包时写入的章节元数据
+type episodeInfo struct {
+	Series string
+	Title  string
+	Number int
+	Author string
+}
+
+// fetchEpisodeInfo 通过章节列表和作品详情接口获取章节的元数据。
+// 元数据不影响下载，获取失败时记录日志并返回已经拿到的部分。
+func fetchEpisodeInfo(ctx context.Context, client *httpx.Client, episodeID string) episodeInfo {
+	var info episodeInfo
+	var list episodeList
+	if err := fetchAPI(ctx, client, "/web/episode/list", map[string]string{"episode_id_list": episodeID}, &list); err != nil {
+		log.Printf("[PocketShonenmagazine] 获取章节信息失败: %v", err)
+		return info
+	}
+	if len(list.EpisodeList) == 0 {
+		return info
+	}
+	e := list.EpisodeList[0]
+	info.Title = e.EpisodeName
+	info.Number = e.Index
+	if e.TitleID == 0 {
+		return info
+	}
+
+	var detail titleDetail
+	params := map[string]string{"title_id": strconv.Itoa(e.TitleID)}
+	if err := fetchAPI(ctx, client, "/web/title/detail", params, &detail); err != nil {
+		log.Printf("[PocketShonenmagazine] 获取作品信息失败: %v", err)
+		return info
+	}
+	info.Series = detail.WebTitle.TitleName
+	info.Author = detail.WebTitle.AuthorText
+	return info
+}
+
+func extractTitleID(url string) string {
+	regex := regexp.MustCompile(`title/(\d+)`)
+	matches := regex.FindStringSubmatch(url)
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// parseStartTime 解析章节的发布时间，格式无法识别时返回零值
+func parseStartTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
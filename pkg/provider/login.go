@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 因登录问题失败时返回的错误，可以用 errors.Is 判断
+var (
+	// ErrNotLoggedIn 没有登录 cookie，需要导入 cookie 或登录
+	ErrNotLoggedIn = errors.New("未登录")
+	// ErrCookieExpired 登录 cookie 已过期或不再被站点接受，需要重新导入
+	ErrCookieExpired = errors.New("登录 cookie 已过期")
+)
+
+// IsLoginError 判断 err 是否因为未登录或登录过期
+func IsLoginError(err error) bool {
+	return errors.Is(err, ErrNotLoggedIn) || errors.Is(err, ErrCookieExpired)
+}
+
+// LoginStatus 站点的登录状态
+type LoginStatus struct {
+	Site     string `json:"site"`
+	LoggedIn bool   `json:"logged_in"`
+	// User 登录的用户名，站点不提供时为空
+	User string `json:"user,omitempty"`
+	// Expires 登录 cookie 的过期时间，会话 cookie 为零值
+	Expires time.Time `json:"expires,omitzero"`
+	// Reason 未登录的原因
+	Reason string `json:"reason,omitempty"`
+}
+
+// LoginChecker 能检查登录状态的站点
+type LoginChecker interface {
+	// CheckLogin 用保存的 cookie 检查是否已登录。未登录时返回状态和 ErrNotLoggedIn 或 ErrCookieExpired，
+	// 其他错误表示无法判断，如网络错误
+	CheckLogin(ctx context.Context) (*LoginStatus, error)
+}
+
+// CheckLogin 检查站点的登录状态
+func CheckLogin(ctx context.Context, p Provider) (*LoginStatus, error) {
+	lc, ok := p.(LoginChecker)
+	if !ok {
+		return nil, fmt.Errorf("%s 不支持检查登录状态", p.Name())
+	}
+	status, err := lc.CheckLogin(ctx)
+	if status != nil {
+		status.Site = p.Name()
+		if err != nil && status.Reason == "" {
+			status.Reason = err.Error()
+		}
+	}
+	return status, err
+}
+
+// PasswordLogin 可以用用户名和密码登录的站点
+type PasswordLogin interface {
+	// Login 登录并把登录 cookie 保存到站点的 cookie 文件
+	Login(ctx context.Context, user, password string) (*LoginStatus, error)
+}
+
+// Login 用用户名和密码登录站点
+func Login(ctx context.Context, p Provider, user, password string) (*LoginStatus, error) {
+	pl, ok := p.(PasswordLogin)
+	if !ok {
+		return nil, fmt.Errorf("%s 不支持用户名和密码登录", p.Name())
+	}
+	status, err := pl.Login(ctx, user, password)
+	if status != nil {
+		status.Site = p.Name()
+	}
+	return status, err
+}
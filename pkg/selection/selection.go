@@ -0,0 +1,136 @@
+// Package selection 解析批量下载时的章节选择表达式，例如 "1-10,15,latest:3,free"
+package selection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// Range 闭区间，To 为 0 表示一直到最后
+type Range struct {
+	From int
+	To   int
+}
+
+// Contains 判断 n 是否在区间内
+func (r Range) Contains(n int) bool {
+	return n >= r.From && (r.To == 0 || n <= r.To)
+}
+
+// Episodes 解析后的章节选择，各项之间取并集
+type Episodes struct {
+	all    bool
+	free   bool
+	latest int
+	ranges []Range
+}
+
+// ParseEpisodes 解析章节选择表达式，各项用逗号分隔：
+//
+//	3        第 3 话
+//	1-10     第 1 到 10 话
+//	20-      第 20 话及之后
+//	latest:3 最新的 3 话
+//	free     所有免费章节
+//	all      全部章节
+func ParseEpisodes(expr string) (*Episodes, error) {
+	s := &Episodes{}
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.ToLower(strings.TrimSpace(term))
+		switch {
+		case term == "":
+			continue
+		case term == "all":
+			s.all = true
+		case term == "free":
+			s.free = true
+		case strings.HasPrefix(term, "latest:"):
+			n, err := strconv.Atoi(strings.TrimPrefix(term, "latest:"))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("无效的选择: %s", term)
+			}
+			if n > s.latest {
+				s.latest = n
+			}
+		default:
+			r, err := parseRange(term)
+			if err != nil {
+				return nil, err
+			}
+			s.ranges = append(s.ranges, r)
+		}
+	}
+	if !s.all && !s.free && s.latest == 0 && len(s.ranges) == 0 {
+		return nil, fmt.Errorf("选择为空")
+	}
+	return s, nil
+}
+
+// Apply 返回被选中的章节，保持原有顺序；episodes 需按话数从小到大排列
+func (s *Episodes) Apply(episodes []provider.SeriesEpisode) []provider.SeriesEpisode {
+	var selected []provider.SeriesEpisode
+	for i, e := range episodes {
+		if s.match(e, len(episodes)-i) {
+			selected = append(selected, e)
+		}
+	}
+	return selected
+}
+
+// match 判断一话是否被选中，fromEnd 为倒数第几话
+func (s *Episodes) match(e provider.SeriesEpisode, fromEnd int) bool {
+	if s.all || (s.free && e.Free) || fromEnd <= s.latest {
+		return true
+	}
+	for _, r := range s.ranges {
+		if r.Contains(e.Number) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRanges 解析只包含数字区间的表达式，例如 "1-5,20-"
+func ParseRanges(expr string) ([]Range, error) {
+	var ranges []Range
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		r, err := parseRange(term)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("选择为空")
+	}
+	return ranges, nil
+}
+
+// parseRange 解析 "3"、"1-10"、"20-" 形式的一项
+func parseRange(term string) (Range, error) {
+	from, to, isRange := strings.Cut(term, "-")
+	start, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || start < 1 {
+		return Range{}, fmt.Errorf("无效的选择: %s", term)
+	}
+	if !isRange {
+		return Range{From: start, To: start}, nil
+	}
+
+	to = strings.TrimSpace(to)
+	if to == "" {
+		return Range{From: start}, nil
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil || end < start {
+		return Range{}, fmt.Errorf("无效的选择: %s", term)
+	}
+	return Range{From: start, To: end}, nil
+}
@@ -0,0 +1,143 @@
+package selection
+
+import (
+	"reflect"
+	"testing"
+
+	"mg-Downloader/pkg/provider"
+)
+
+// testEpisodes 第 1 到 6 话，中间有一话番外（没有话数），第 2、3 话免费
+func testEpisodes() []provider.SeriesEpisode {
+	return []provider.SeriesEpisode{
+		{ID: "a", Number: 1},
+		{ID: "b", Number: 2, Free: true},
+		{ID: "c", Number: 3, Free: true},
+		{ID: "x", Number: 0},
+		{ID: "d", Number: 4},
+		{ID: "e", Number: 5},
+		{ID: "f", Number: 6},
+	}
+}
+
+func ids(episodes []provider.SeriesEpisode) []string {
+	var out []string
+	for _, e := range episodes {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestParseEpisodes(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"3", []string{"c"}},
+		{"2-4", []string{"b", "c", "d"}},
+		{"5-", []string{"e", "f"}},
+		{" 1 , 6 ", []string{"a", "f"}},
+		{"4-4", []string{"d"}},
+		{"latest:2", []string{"e", "f"}},
+		{"latest:4", []string{"x", "d", "e", "f"}},
+		{"latest:1,latest:3", []string{"d", "e", "f"}},
+		{"latest:100", []string{"a", "b", "c", "x", "d", "e", "f"}},
+		{"free", []string{"b", "c"}},
+		{"FREE,6", []string{"b", "c", "f"}},
+		{"all", []string{"a", "b", "c", "x", "d", "e", "f"}},
+		{"All,1", []string{"a", "b", "c", "x", "d", "e", "f"}},
+		// 重叠的项只选中一次，保持原有顺序
+		{"1-3,2-4,3", []string{"a", "b", "c", "d"}},
+		{"6,1,free", []string{"a", "b", "c", "f"}},
+		{"1,,2,", []string{"a", "b"}},
+		// 超出范围的话数不报错
+		{"10-", nil},
+	}
+	for _, tt := range tests {
+		sel, err := ParseEpisodes(tt.expr)
+		if err != nil {
+			t.Errorf("ParseEpisodes(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := ids(sel.Apply(testEpisodes())); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseEpisodes(%q) selected %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseEpisodesInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		" , ",
+		"0",
+		"-3",
+		"5-3",
+		"1-2-3",
+		"a-b",
+		"1.5",
+		"latest",
+		"latest:",
+		"latest:0",
+		"latest:-1",
+		"latest:x",
+		"newest:3",
+		"1,two",
+	} {
+		if _, err := ParseEpisodes(expr); err == nil {
+			t.Errorf("ParseEpisodes(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestParseRanges(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []Range
+	}{
+		{"3", []Range{{3, 3}}},
+		{"1-5,20-", []Range{{1, 5}, {20, 0}}},
+		{" 2 - 4 ", []Range{{2, 4}}},
+		{"7,7", []Range{{7, 7}, {7, 7}}},
+		{"1,,3", []Range{{1, 1}, {3, 3}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRanges(tt.expr)
+		if err != nil {
+			t.Errorf("ParseRanges(%q): %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRanges(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	// 页码选择不支持章节专用的关键字
+	for _, expr := range []string{"", ",", "0-2", "3-1", "all", "latest:2", "free", "x"} {
+		if _, err := ParseRanges(expr); err == nil {
+			t.Errorf("ParseRanges(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		r    Range
+		n    int
+		want bool
+	}{
+		{Range{3, 3}, 3, true},
+		{Range{3, 3}, 4, false},
+		{Range{2, 5}, 1, false},
+		{Range{2, 5}, 5, true},
+		{Range{20, 0}, 20, true},
+		{Range{20, 0}, 1000, true},
+		{Range{20, 0}, 19, false},
+		// 没有话数的章节不会被区间选中
+		{Range{1, 0}, 0, false},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Contains(tt.n); got != tt.want {
+			t.Errorf("%+v.Contains(%d) = %v, want %v", tt.r, tt.n, got, tt.want)
+		}
+	}
+}
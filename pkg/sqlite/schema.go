@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"errors"
+	"strings"
+)
+
+// tableConstraints 以这些关键字开头的定义是表约束而不是列
+var tableConstraints = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "UNIQUE": true, "CHECK": true, "FOREIGN": true,
+}
+
+// parseColumns 从 CREATE TABLE 语句中取出列名，以及作为 rowid 别名的 INTEGER PRIMARY KEY 列
+func parseColumns(sql string) ([]string, int, error) {
+	open := strings.IndexByte(sql, '(')
+	end := strings.LastIndexByte(sql, ')')
+	if open < 0 || end <= open {
+		return nil, -1, errors.New("无法解析表结构")
+	}
+
+	var columns []string
+	rowidColumn := -1
+	for _, def := range splitTopLevel(sql[open+1 : end]) {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		if tableConstraints[strings.ToUpper(fields[0])] {
+			continue
+		}
+		name := unquote(fields[0])
+		rest := strings.ToUpper(strings.Join(fields[1:], " "))
+		if strings.HasPrefix(rest, "INTEGER") && strings.Contains(rest, "PRIMARY KEY") && !strings.Contains(rest, "PRIMARY KEY DESC") {
+			rowidColumn = len(columns)
+		}
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		return nil, -1, errors.New("表中没有列")
+	}
+	return columns, rowidColumn, nil
+}
+
+// splitTopLevel 按不在括号和引号中的逗号切分
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unquote(name string) string {
+	if len(name) >= 2 {
+		switch {
+		case name[0] == '"' && name[len(name)-1] == '"',
+			name[0] == '`' && name[len(name)-1] == '`',
+			name[0] == '[' && name[len(name)-1] == ']':
+			return name[1 : len(name)-1]
+		}
+	}
+	return name
+}
@@ -0,0 +1,441 @@
+// Package sqlite 是一个只读的 SQLite 数据库文件读取器，只支持按表顺序读取行，
+// 用于从浏览器的 cookie 数据库中导入 cookie，不需要 cgo。
+//
+// 数据库整个读入内存，不会加锁也不会修改文件，浏览器运行时也可以读取；
+// 同目录下的 -wal 文件中已提交的页面会覆盖主文件中的旧页面。
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+const headerMagic = "SQLite format 3\x00"
+
+// 页面类型
+const (
+	pageTableInterior = 0x05
+	pageTableLeaf     = 0x0d
+)
+
+// maxDepth b-tree 的最大深度，防止损坏的文件造成死循环
+const maxDepth = 64
+
+// ErrNoTable 表不存在
+var ErrNoTable = errors.New("表不存在")
+
+// DB 打开的数据库
+type DB struct {
+	data     []byte
+	pageSize int
+	usable   int
+	// wal -wal 文件中最后一次提交的页面，按页号索引
+	wal map[uint32][]byte
+}
+
+// Open 读取数据库文件和同目录下的 -wal 文件
+func Open(path string) (*DB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库失败: %w", err)
+	}
+	db, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if wal, err := os.ReadFile(path + "-wal"); err == nil {
+		db.wal = readWAL(wal, db.pageSize)
+	}
+	return db, nil
+}
+
+func parse(data []byte) (*DB, error) {
+	if len(data) < 100 || string(data[:16]) != headerMagic {
+		return nil, errors.New("不是 SQLite 数据库")
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("无效的页面大小 %d", pageSize)
+	}
+	if enc := binary.BigEndian.Uint32(data[56:60]); enc != 0 && enc != 1 {
+		return nil, errors.New("只支持 UTF-8 编码的数据库")
+	}
+	return &DB{
+		data:     data,
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+	}, nil
+}
+
+// readWAL 解析 -wal 文件，只采用最后一个提交点之前、salt 与文件头一致且校验和正确的帧。
+// 校验和从文件头开始逐帧累加，遇到第一个无效的帧就停止，之后的帧属于旧的或未写完的事务。
+func readWAL(wal []byte, pageSize int) map[uint32][]byte {
+	if len(wal) < 32 {
+		return nil
+	}
+	magic := binary.BigEndian.Uint32(wal[0:4])
+	if magic != 0x377f0682 && magic != 0x377f0683 {
+		return nil
+	}
+	if int(binary.BigEndian.Uint32(wal[8:12])) != pageSize {
+		return nil
+	}
+	// magic 的最低位决定校验和按大端还是小端读取
+	order := binary.ByteOrder(binary.LittleEndian)
+	if magic&1 == 1 {
+		order = binary.BigEndian
+	}
+	s0, s1 := walChecksum(order, wal[:24], 0, 0)
+	if s0 != binary.BigEndian.Uint32(wal[24:28]) || s1 != binary.BigEndian.Uint32(wal[28:32]) {
+		return nil
+	}
+	salt1, salt2 := binary.BigEndian.Uint32(wal[16:20]), binary.BigEndian.Uint32(wal[20:24])
+
+	committed := make(map[uint32][]byte)
+	pending := make(map[uint32][]byte)
+	frameSize := 24 + pageSize
+	for off := 32; off+frameSize <= len(wal); off += frameSize {
+		frame := wal[off : off+frameSize]
+		if binary.BigEndian.Uint32(frame[8:12]) != salt1 || binary.BigEndian.Uint32(frame[12:16]) != salt2 {
+			break
+		}
+		// 校验和覆盖帧头的前 8 字节和页面内容
+		s0, s1 = walChecksum(order, frame[:8], s0, s1)
+		s0, s1 = walChecksum(order, frame[24:], s0, s1)
+		if s0 != binary.BigEndian.Uint32(frame[16:20]) || s1 != binary.BigEndian.Uint32(frame[20:24]) {
+			break
+		}
+		pending[binary.BigEndian.Uint32(frame[0:4])] = frame[24:]
+		// 第二个字段不为 0 表示这一帧是一次事务的提交
+		if binary.BigEndian.Uint32(frame[4:8]) != 0 {
+			for k, v := range pending {
+				committed[k] = v
+			}
+			pending = make(map[uint32][]byte)
+		}
+	}
+	return committed
+}
+
+// walChecksum 在 s0、s1 的基础上累加 data 的校验和，data 的长度是 8 的倍数，见 SQLite 文件格式文档 4.1 节
+func walChecksum(order binary.ByteOrder, data []byte, s0, s1 uint32) (uint32, uint32) {
+	for i := 0; i+8 <= len(data); i += 8 {
+		s0 += order.Uint32(data[i:]) + s1
+		s1 += order.Uint32(data[i+4:]) + s0
+	}
+	return s0, s1
+}
+
+// page 返回页号为 n（从 1 开始）的页面
+func (db *DB) page(n uint32) ([]byte, error) {
+	if p, ok := db.wal[n]; ok {
+		return p, nil
+	}
+	start := int64(n-1) * int64(db.pageSize)
+	if n == 0 || start+int64(db.pageSize) > int64(len(db.data)) {
+		return nil, fmt.Errorf("页号 %d 超出文件范围", n)
+	}
+	return db.data[start : start+int64(db.pageSize)], nil
+}
+
+// Table 表的结构
+type Table struct {
+	Name     string
+	Columns  []string
+	rootPage uint32
+	// rowidColumn INTEGER PRIMARY KEY 列的下标，该列的值保存为 rowid，-1 表示没有
+	rowidColumn int
+}
+
+// Table 从 sqlite_schema 中查找表
+func (db *DB) Table(name string) (*Table, error) {
+	var found *Table
+	err := db.walk(1, 0, func(rowid int64, values []any) error {
+		if len(values) < 5 || values[0] != "table" {
+			return nil
+		}
+		tblName, _ := values[1].(string)
+		if !strings.EqualFold(tblName, name) {
+			return nil
+		}
+		root, _ := values[3].(int64)
+		sql, _ := values[4].(string)
+		columns, rowidColumn, err := parseColumns(sql)
+		if err != nil {
+			return fmt.Errorf("表 %s: %w", name, err)
+		}
+		found = &Table{Name: tblName, Columns: columns, rootPage: uint32(root), rowidColumn: rowidColumn}
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoTable, name)
+	}
+	return found, nil
+}
+
+// Has 表中是否有名为 column 的列
+func (t *Table) Has(column string) bool {
+	return t.index(column) >= 0
+}
+
+func (t *Table) index(column string) int {
+	for i, c := range t.Columns {
+		if strings.EqualFold(c, column) {
+			return i
+		}
+	}
+	return -1
+}
+
+var errStop = errors.New("stop")
+
+// Select 按顺序读取表中每一行的指定列，交给 fn。
+// 值的类型为 nil、int64、float64、string 或 []byte；旧版本中新增的列读出为 nil。
+func (db *DB) Select(table string, columns []string, fn func(values []any) error) error {
+	t, err := db.Table(table)
+	if err != nil {
+		return err
+	}
+	idx := make([]int, len(columns))
+	for i, c := range columns {
+		if idx[i] = t.index(c); idx[i] < 0 {
+			return fmt.Errorf("表 %s 中没有列 %s", table, c)
+		}
+	}
+
+	out := make([]any, len(columns))
+	return db.walk(t.rootPage, 0, func(rowid int64, values []any) error {
+		for i, j := range idx {
+			switch {
+			case j == t.rowidColumn:
+				out[i] = rowid
+			case j < len(values):
+				out[i] = values[j]
+			default:
+				out[i] = nil
+			}
+		}
+		return fn(out)
+	})
+}
+
+// walk 按 rowid 顺序遍历表 b-tree
+func (db *DB) walk(pageNo uint32, depth int, fn func(rowid int64, values []any) error) error {
+	if depth > maxDepth {
+		return errors.New("b-tree 层数过多，文件可能已损坏")
+	}
+	page, err := db.page(pageNo)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if pageNo == 1 {
+		hdr = 100
+	}
+	if hdr+8 > len(page) {
+		return fmt.Errorf("页面 %d 损坏", pageNo)
+	}
+
+	kind := page[hdr]
+	cells := int(binary.BigEndian.Uint16(page[hdr+3:]))
+	ptrs := hdr + 8
+	if kind == pageTableInterior {
+		ptrs = hdr + 12
+	}
+	if ptrs+2*cells > len(page) {
+		return fmt.Errorf("页面 %d 损坏", pageNo)
+	}
+
+	switch kind {
+	case pageTableInterior:
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(page[ptrs+2*i:]))
+			if off+4 > len(page) {
+				return fmt.Errorf("页面 %d 损坏", pageNo)
+			}
+			if err := db.walk(binary.BigEndian.Uint32(page[off:]), depth+1, fn); err != nil {
+				return err
+			}
+		}
+		return db.walk(binary.BigEndian.Uint32(page[hdr+8:]), depth+1, fn)
+
+	case pageTableLeaf:
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(page[ptrs+2*i:]))
+			rowid, payload, err := db.leafCell(page, off)
+			if err != nil {
+				return fmt.Errorf("页面 %d: %w", pageNo, err)
+			}
+			values, err := decodeRecord(payload)
+			if err != nil {
+				return fmt.Errorf("页面 %d: %w", pageNo, err)
+			}
+			if err := fn(rowid, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("页面 %d 不是表 b-tree 页面（类型 %#x）", pageNo, kind)
+}
+
+// leafCell 读取叶子页中的一个单元，超出页面的内容从溢出页中拼接
+func (db *DB) leafCell(page []byte, off int) (int64, []byte, error) {
+	if off >= len(page) {
+		return 0, nil, errors.New("单元偏移越界")
+	}
+	size, n := varint(page[off:])
+	off += n
+	rowid, n := varint(page[off:])
+	off += n
+	if n == 0 || size > math.MaxInt32 {
+		return 0, nil, errors.New("单元损坏")
+	}
+
+	total := int(size)
+	local := db.localSize(total)
+	if off+local > len(page) {
+		return 0, nil, errors.New("单元内容越界")
+	}
+	if local == total {
+		return int64(rowid), page[off : off+total], nil
+	}
+
+	payload := make([]byte, 0, total)
+	payload = append(payload, page[off:off+local]...)
+	if off+local+4 > len(page) {
+		return 0, nil, errors.New("溢出页号越界")
+	}
+	next := binary.BigEndian.Uint32(page[off+local:])
+	for visited := 0; len(payload) < total; visited++ {
+		if next == 0 || visited > len(db.data)/db.pageSize+len(db.wal) {
+			return 0, nil, errors.New("溢出页链损坏")
+		}
+		overflow, err := db.page(next)
+		if err != nil {
+			return 0, nil, err
+		}
+		chunk := min(total-len(payload), db.usable-4)
+		payload = append(payload, overflow[4:4+chunk]...)
+		next = binary.BigEndian.Uint32(overflow)
+	}
+	return int64(rowid), payload, nil
+}
+
+// localSize 表叶子单元保存在页面内的字节数，见 SQLite 文件格式文档 1.6 节
+func (db *DB) localSize(total int) int {
+	u := db.usable
+	x := u - 35
+	if total <= x {
+		return total
+	}
+	m := (u-12)*32/255 - 23
+	k := m + (total-m)%(u-4)
+	if k <= x {
+		return k
+	}
+	return m
+}
+
+// decodeRecord 解析记录格式的一行
+func decodeRecord(p []byte) ([]any, error) {
+	hdrSize, n := varint(p)
+	if n == 0 || hdrSize > uint64(len(p)) {
+		return nil, errors.New("记录头损坏")
+	}
+	var types []uint64
+	for off := n; off < int(hdrSize); {
+		t, n := varint(p[off:])
+		if n == 0 {
+			return nil, errors.New("记录头损坏")
+		}
+		types = append(types, t)
+		off += n
+	}
+
+	values := make([]any, len(types))
+	body := p[hdrSize:]
+	for i, t := range types {
+		size := serialSize(t)
+		if size > len(body) {
+			return nil, errors.New("记录内容越界")
+		}
+		v := body[:size]
+		body = body[size:]
+		switch {
+		case t == 0:
+			values[i] = nil
+		case t >= 1 && t <= 6:
+			values[i] = bigEndianInt(v)
+		case t == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(v))
+		case t == 8:
+			values[i] = int64(0)
+		case t == 9:
+			values[i] = int64(1)
+		case t >= 12 && t%2 == 0:
+			values[i] = bytes.Clone(v)
+		case t >= 13:
+			values[i] = string(v)
+		default:
+			return nil, fmt.Errorf("未知的数据类型 %d", t)
+		}
+	}
+	return values, nil
+}
+
+// serialSize 各类型值占用的字节数
+func serialSize(t uint64) int {
+	switch {
+	case t <= 4:
+		return int(t)
+	case t == 5:
+		return 6
+	case t == 6, t == 7:
+		return 8
+	case t >= 12:
+		return int((t - 12) / 2)
+	}
+	return 0
+}
+
+// bigEndianInt 解析 1 到 8 字节的有符号大端整数
+func bigEndianInt(b []byte) int64 {
+	var v int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+// varint 解析 SQLite 的变长整数，返回值和占用的字节数，数据不完整时字节数为 0
+func varint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, 9
+}
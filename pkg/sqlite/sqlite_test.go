@@ -0,0 +1,214 @@
+package sqlite
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 测试用的数据库由 testdata/gen.py 生成
+
+// selectAll 读取表中的指定列，每行复制一份
+func selectAll(t *testing.T, db *DB, table string, columns ...string) [][]any {
+	t.Helper()
+	var rows [][]any
+	err := db.Select(table, columns, func(values []any) error {
+		rows = append(rows, append([]any(nil), values...))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Select(%s): %v", table, err)
+	}
+	return rows
+}
+
+// openWAL 把 wal.sqlite 复制到临时目录后打开；withWAL 为 false 时不复制 -wal 文件，
+// mutate 不为 nil 时先修改 -wal 文件的内容
+func openWAL(t *testing.T, withWAL bool, mutate func(wal []byte)) *DB {
+	t.Helper()
+	dir := t.TempDir()
+	data, err := os.ReadFile(filepath.Join("testdata", "wal.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "wal.sqlite")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if withWAL {
+		wal, err := os.ReadFile(filepath.Join("testdata", "wal.sqlite-wal"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mutate != nil {
+			mutate(wal)
+		}
+		if err := os.WriteFile(path+"-wal", wal, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSmall(t *testing.T) {
+	db, err := Open(filepath.Join("testdata", "small.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := db.Table("COOKIES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantColumns := []string{"id", "name", "value", "expires", "ratio", "note", "extra"}
+	if !reflect.DeepEqual(table.Columns, wantColumns) {
+		t.Errorf("Columns = %q, want %q", table.Columns, wantColumns)
+	}
+	if !table.Has("Expires") || table.Has("missing") {
+		t.Errorf("Has gives wrong answers")
+	}
+
+	got := selectAll(t, db, "cookies", "id", "name", "value", "expires", "ratio", "note", "extra")
+	want := [][]any{
+		{int64(1), "glsc", []byte{0x00, 0xff, 0x10}, int64(13380000000000000), 0.5, nil, nil},
+		{int64(2), "名前", []byte{}, int64(-1), -2.25, "note", nil},
+		{int64(7), "zero", nil, int64(0), 1.0, "", nil},
+		{int64(8), "new", nil, int64(1), nil, nil, "extra"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		// REAL 列中的整数值按整数保存
+		if f, ok := got[i][4].(int64); ok {
+			got[i][4] = float64(f)
+		}
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("row %d = %#v, want %#v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMissing(t *testing.T) {
+	db, err := Open(filepath.Join("testdata", "small.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Table("nope"); !errors.Is(err, ErrNoTable) {
+		t.Errorf("Table(nope) = %v, want ErrNoTable", err)
+	}
+	err = db.Select("cookies", []string{"missing"}, func([]any) error { return nil })
+	if err == nil {
+		t.Error("Select of a missing column succeeded")
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.sqlite")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("x"), 200), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Open of a non-database file succeeded")
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	db, err := Open(filepath.Join("testdata", "overflow.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := selectAll(t, db, "t", "id", "body", "data")
+	if len(rows) != 501 {
+		t.Fatalf("got %d rows, want 501", len(rows))
+	}
+	for i, row := range rows[:500] {
+		if row[0] != int64(i+1) || row[1] != "row "+strconv.Itoa(i+1) {
+			t.Fatalf("row %d = %#v", i, row)
+		}
+	}
+
+	last := rows[500]
+	if last[0] != int64(1000) {
+		t.Errorf("id = %v, want 1000", last[0])
+	}
+	if last[1] != strings.Repeat("あ", 5000) {
+		t.Errorf("body has %d bytes, want %d", len(last[1].(string)), len(strings.Repeat("あ", 5000)))
+	}
+	want := make([]byte, 3000)
+	for i := range want {
+		want[i] = byte(i % 251)
+	}
+	if !bytes.Equal(last[2].([]byte), want) {
+		t.Error("data does not match")
+	}
+}
+
+func TestWAL(t *testing.T) {
+	db := openWAL(t, true, nil)
+	got := selectAll(t, db, "t", "id", "name")
+	want := [][]any{{int64(1), "uno"}, {int64(4), "four"}, {int64(5), "five"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("t = %v, want %v", got, want)
+	}
+	// 只在 -wal 中创建的表
+	got = selectAll(t, db, "later", "id", "name")
+	if want := [][]any{{int64(1), "later"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("later = %v, want %v", got, want)
+	}
+}
+
+func TestWithoutWAL(t *testing.T) {
+	db := openWAL(t, false, nil)
+	got := selectAll(t, db, "t", "id", "name")
+	want := [][]any{{int64(1), "one"}, {int64(2), "two"}, {int64(3), "three"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("t = %v, want %v", got, want)
+	}
+	if _, err := db.Table("later"); !errors.Is(err, ErrNoTable) {
+		t.Errorf("Table(later) = %v, want ErrNoTable", err)
+	}
+}
+
+func TestWALInvalidFrames(t *testing.T) {
+	const pageSize = 4096
+	// 最后一个事务只有一帧，删除了第 2 行
+	lastFrame := func(wal []byte) []byte {
+		return wal[len(wal)-24-pageSize:]
+	}
+	tests := []struct {
+		name   string
+		mutate func(wal []byte)
+		want   [][]any
+	}{
+		{
+			name:   "page checksum",
+			mutate: func(wal []byte) { lastFrame(wal)[24+100] ^= 0xff },
+			want:   [][]any{{int64(1), "uno"}, {int64(2), "two"}, {int64(4), "four"}, {int64(5), "five"}},
+		},
+		{
+			name:   "frame salt",
+			mutate: func(wal []byte) { lastFrame(wal)[8] ^= 0xff },
+			want:   [][]any{{int64(1), "uno"}, {int64(2), "two"}, {int64(4), "four"}, {int64(5), "five"}},
+		},
+		{
+			name:   "header checksum",
+			mutate: func(wal []byte) { wal[24] ^= 0xff },
+			want:   [][]any{{int64(1), "one"}, {int64(2), "two"}, {int64(3), "three"}},
+		},
+	}
+	for _, tt := range tests {
+		db := openWAL(t, true, tt.mutate)
+		if got := selectAll(t, db, "t", "id", "name"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: t = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
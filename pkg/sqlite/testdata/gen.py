@@ -0,0 +1,78 @@
+#!/usr/bin/env python3
+"""生成 sqlite 包测试用的数据库文件，在 testdata 目录中运行：python3 gen.py"""
+import os
+import shutil
+import sqlite3
+
+
+def remove(*names):
+    for name in names:
+        for suffix in ("", "-wal", "-shm", "-journal"):
+            if os.path.exists(name + suffix):
+                os.remove(name + suffix)
+
+
+def small():
+    """一页就能放下的小表，包含各种类型的值和后来新增的列"""
+    remove("small.sqlite")
+    db = sqlite3.connect("small.sqlite", isolation_level=None)
+    db.executescript("""
+        PRAGMA page_size = 4096;
+        CREATE TABLE cookies (id INTEGER PRIMARY KEY, name TEXT NOT NULL, value BLOB, expires INTEGER, ratio REAL, note TEXT);
+        INSERT INTO cookies VALUES (1, 'glsc', x'00ff10', 13380000000000000, 0.5, NULL);
+        INSERT INTO cookies VALUES (2, '名前', x'', -1, -2.25, 'note');
+        INSERT INTO cookies VALUES (7, 'zero', NULL, 0, 1, '');
+        ALTER TABLE cookies ADD COLUMN extra TEXT;
+        INSERT INTO cookies VALUES (8, 'new', NULL, 1, NULL, NULL, 'extra');
+    """)
+    db.close()
+
+
+def overflow():
+    """1024 字节的页面：多层 b-tree 和跨越多个溢出页的值"""
+    remove("overflow.sqlite")
+    db = sqlite3.connect("overflow.sqlite", isolation_level=None)
+    db.executescript("""
+        PRAGMA page_size = 1024;
+        CREATE TABLE t (id INTEGER PRIMARY KEY, body TEXT, data BLOB);
+    """)
+    db.execute("BEGIN")
+    for i in range(1, 501):
+        db.execute("INSERT INTO t (id, body) VALUES (?, ?)", (i, "row %d" % i))
+    db.execute("INSERT INTO t VALUES (1000, ?, ?)", ("あ" * 5000, bytes(i % 251 for i in range(3000))))
+    db.execute("COMMIT")
+    db.close()
+
+
+def wal():
+    """前三行已写回主文件，之后的插入、修改、删除和新建的表只在 -wal 文件中，
+    最后一个事务只删除了第 2 行"""
+    remove("wal.sqlite")
+    db = sqlite3.connect("wal.sqlite", isolation_level=None)
+    db.executescript("""
+        PRAGMA page_size = 4096;
+        PRAGMA journal_mode = WAL;
+        PRAGMA wal_autocheckpoint = 0;
+        CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT);
+        INSERT INTO t VALUES (1, 'one'), (2, 'two'), (3, 'three');
+        PRAGMA wal_checkpoint(TRUNCATE);
+        INSERT INTO t VALUES (4, 'four');
+        INSERT INTO t VALUES (5, 'five');
+        UPDATE t SET name = 'uno' WHERE id = 1;
+        DELETE FROM t WHERE id = 3;
+        CREATE TABLE later (id INTEGER PRIMARY KEY, name TEXT);
+        INSERT INTO later VALUES (1, 'later');
+        DELETE FROM t WHERE id = 2;
+    """)
+    # 关闭连接会把 -wal 写回主文件，在打开时复制
+    shutil.copy("wal.sqlite", "wal.sqlite.tmp")
+    shutil.copy("wal.sqlite-wal", "wal.sqlite-wal.tmp")
+    db.close()
+    remove("wal.sqlite")
+    os.rename("wal.sqlite.tmp", "wal.sqlite")
+    os.rename("wal.sqlite-wal.tmp", "wal.sqlite-wal")
+
+
+small()
+overflow()
+wal()